@@ -49,11 +49,12 @@ resource "redfox_cluster" "aa" {
     cluster_group    = "dev-meta"
     cluster_engine   = "EKS"
     cluster_region   = "ap-northeast-2"
-    infra_account_id = "1234567890"
+    infra_account_id = "123456789012"
     infra_vendor     = "AWS"
     service_phase    = "dev"
     service_tag      = "meta"
     roles = ["central", "ingame"]
+    vpc_id = "vpc-0123456789abcdef0"
   }
 }
 
@@ -65,16 +66,13 @@ resource "redfox_cluster_status" "aa" {
   status {
     apiserver {
       endpoint = "https://example.com"
-      ca_cert = base64encode("MYCERT")
+      ca_cert  = filebase64("${path.module}/ca.crt")
     }
-    service_account_issuer = "https://example.com"
+    service_account_issuer = "https://oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLED539D4633E53DE1B71EXAMPLE"
     aws_iam_idps = {
-      "foo" = "bar"
-      "fizz" = "buzz"
+      "dev" = "arn:aws:iam::123456789012:oidc-provider/oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLED539D4633E53DE1B71EXAMPLE"
     }
   }
-
-  depends_on = [redfox_cluster.aa]
 }
 
 data "redfox_clusters" "a" {
@@ -85,3 +83,22 @@ data "redfox_clusters" "a" {
     }
   }
 }
+
+data "redfox_cluster_trust_policy" "app" {
+  cluster {
+    name      = "my-second-cluster"
+    namespace = "redfox-metadata"
+  }
+  service_account {
+    namespace = "app"
+    name      = "*"
+  }
+}
+
+data "redfox_kubeconfig" "dev" {
+  namespace = "redfox-metadata"
+  exec {
+    command = "aws"
+    args    = ["eks", "get-token", "--cluster-name", "{{.ClusterName}}", "--region", "{{.ClusterRegion}}"]
+  }
+}
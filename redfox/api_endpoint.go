@@ -0,0 +1,154 @@
+package redfox
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+const (
+	endpointSeverityWarning = "warning"
+	endpointSeverityError   = "error"
+
+	defaultEndpointVerificationTimeout = 10 * time.Second
+)
+
+func verifyEndpointSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Before writing the status, perform a TLS handshake and `GET /version` against `apiserver.endpoint`, trusting only `apiserver.ca_cert`. Requests go through the provider `proxy_url`.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"severity": {
+					Type:         schema.TypeString,
+					Description:  "Whether an unreachable endpoint, or a certificate expired or not signed by `ca_cert`, is reported as an `error` failing the apply or as a `warning`.",
+					Optional:     true,
+					Default:      endpointSeverityError,
+					ValidateFunc: validation.StringInSlice([]string{endpointSeverityWarning, endpointSeverityError}, false),
+				},
+				"timeout": {
+					Type:         schema.TypeString,
+					Description:  "Timeout of the verification, e.g. `30s`.",
+					Optional:     true,
+					Default:      defaultEndpointVerificationTimeout.String(),
+					ValidateFunc: validateDuration,
+				},
+			},
+		},
+	}
+}
+
+// apiserverHTTPClient returns a client which goes through the proxy of the provider and trusts
+// nothing but the certificates of caCert.
+func apiserverHTTPClient(meta interface{}, caCert string, timeout time.Duration) (*http.Client, error) {
+	certs, err := decodeCaCert(caCert)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	transport := providerHTTPTransport(meta)
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// verifyApiserverEndpoint checks that endpoint serves the Kubernetes API with a certificate which
+// client trusts. Problems are reported with severity.
+func verifyApiserverEndpoint(ctx context.Context, client *http.Client, endpoint string, severity diag.Severity) diag.Diagnostics {
+	path := cty.GetAttrPath("status").IndexInt(0).GetAttr("apiserver").IndexInt(0).GetAttr("endpoint")
+	problem := func(summary, detail string) diag.Diagnostics {
+		return diag.Diagnostics{{
+			Severity:      severity,
+			Summary:       summary,
+			Detail:        detail,
+			AttributePath: path,
+		}}
+	}
+
+	versionURL := strings.TrimSuffix(endpoint, "/") + "/version"
+	tflog.Info(ctx, fmt.Sprintf("Verifying API server endpoint %s", versionURL))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, versionURL, nil)
+	if err != nil {
+		return problem("Invalid API server endpoint", err.Error())
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		var unknownAuthority x509.UnknownAuthorityError
+		var invalid x509.CertificateInvalidError
+		var hostname x509.HostnameError
+		switch {
+		case errors.As(err, &unknownAuthority):
+			return problem("API server certificate is not signed by ca_cert",
+				fmt.Sprintf("%s presented a certificate issued by %q, which status.apiserver.ca_cert does not contain. Clients using this status would reject the API server.", endpoint, unknownAuthority.Cert.Issuer.String()))
+		case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+			return problem("API server certificate has expired", fmt.Sprintf("%s: %s", endpoint, invalid.Error()))
+		case errors.As(err, &hostname):
+			return problem("API server certificate does not match the endpoint", fmt.Sprintf("%s: %s", endpoint, hostname.Error()))
+		}
+		return problem("API server endpoint is unreachable", err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return problem("API server endpoint is unreachable", err.Error())
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// The handshake proved the certificate, anonymous requests are just not allowed to see the version
+		tflog.Info(ctx, fmt.Sprintf("GET %s returned %s, skipping the version check", versionURL, resp.Status))
+		return nil
+	default:
+		return problem("API server endpoint does not serve the Kubernetes API", fmt.Sprintf("GET %s returned %s", versionURL, resp.Status))
+	}
+
+	var info version.Info
+	if err := json.Unmarshal(body, &info); err != nil || info.GitVersion == "" {
+		return problem("API server endpoint does not serve the Kubernetes API", fmt.Sprintf("GET %s did not return a Kubernetes version", versionURL))
+	}
+	tflog.Info(ctx, fmt.Sprintf("API server %s runs Kubernetes %s", endpoint, info.GitVersion))
+	return nil
+}
+
+// verifyEndpoint runs the `verify_endpoint` block of redfox_cluster_status, if any, for endpoint and caCert.
+func verifyEndpoint(ctx context.Context, l []interface{}, endpoint, caCert string, meta interface{}) diag.Diagnostics {
+	if len(l) == 0 || l[0] == nil {
+		return nil
+	}
+	in := l[0].(map[string]interface{})
+	severity := diag.Error
+	if in["severity"].(string) == endpointSeverityWarning {
+		severity = diag.Warning
+	}
+	timeout, err := time.ParseDuration(in["timeout"].(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	client, err := apiserverHTTPClient(meta, caCert, timeout)
+	if err != nil {
+		return diag.Errorf("status.0.apiserver.0.ca_cert %s", err)
+	}
+	return verifyApiserverEndpoint(ctx, client, endpoint, severity)
+}
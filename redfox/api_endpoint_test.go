@@ -0,0 +1,144 @@
+package redfox
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// testApiserver serves the Kubernetes API version with status, over TLS with a certificate for
+// 127.0.0.1 signed by the CA of caCert and caKey and valid until notAfter.
+func testApiserver(t *testing.T, caCert *x509.Certificate, caKey *ecdsa.PrivateKey, notAfter time.Time, status int) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "kube-apiserver"},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"major": "1", "minor": "24", "gitVersion": "v1.24.1"}`)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVerifyEndpoint(t *testing.T) {
+	caCert, caKey, caPem := testCaCert(t, "kubernetes")
+	_, _, otherPem := testCaCert(t, "other")
+
+	cases := []struct {
+		name     string
+		caCert   string
+		notAfter time.Time
+		status   int
+		severity string
+		summary  string
+	}{
+		{
+			name:     "correct CA",
+			caCert:   caPem,
+			severity: endpointSeverityError,
+		},
+		{
+			name:     "correct CA, anonymous requests forbidden",
+			caCert:   caPem,
+			status:   http.StatusForbidden,
+			severity: endpointSeverityError,
+		},
+		{
+			name:     "wrong CA",
+			caCert:   otherPem,
+			severity: endpointSeverityError,
+			summary:  "API server certificate is not signed by ca_cert",
+		},
+		{
+			name:     "wrong CA, warning",
+			caCert:   otherPem,
+			severity: endpointSeverityWarning,
+			summary:  "API server certificate is not signed by ca_cert",
+		},
+		{
+			name:     "expired certificate",
+			caCert:   caPem,
+			notAfter: time.Now().Add(-time.Hour),
+			severity: endpointSeverityError,
+			summary:  "API server certificate has expired",
+		},
+		{
+			name:     "not the Kubernetes API",
+			caCert:   caPem,
+			status:   http.StatusNotFound,
+			severity: endpointSeverityWarning,
+			summary:  "API server endpoint does not serve the Kubernetes API",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.notAfter.IsZero() {
+				tc.notAfter = time.Now().Add(time.Hour)
+			}
+			if tc.status == 0 {
+				tc.status = http.StatusOK
+			}
+			server := testApiserver(t, caCert, caKey, tc.notAfter, tc.status)
+			l := []interface{}{map[string]interface{}{"severity": tc.severity, "timeout": "5s"}}
+			diags := verifyEndpoint(context.Background(), l, server.URL, tc.caCert, nil)
+			if tc.summary == "" {
+				if len(diags) > 0 {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+			severity := diag.Error
+			if tc.severity == endpointSeverityWarning {
+				severity = diag.Warning
+			}
+			if len(diags) != 1 || diags[0].Severity != severity || diags[0].Summary != tc.summary {
+				t.Fatalf("expected one diagnostic %q of severity %v, got %v", tc.summary, severity, diags)
+			}
+			if !strings.Contains(diags[0].Detail, server.URL) {
+				t.Errorf("expected the detail to name %s, got %q", server.URL, diags[0].Detail)
+			}
+		})
+	}
+}
+
+func TestVerifyEndpointWithoutBlock(t *testing.T) {
+	if diags := verifyEndpoint(context.Background(), nil, "https://127.0.0.1:1", "", nil); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+}
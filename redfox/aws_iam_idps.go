@@ -0,0 +1,150 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var awsIamOidcProviderArnRegexp = regexp.MustCompile(`^arn:(aws|aws-cn|aws-us-gov):iam::(\d{12}):oidc-provider/([^/\s]+(/[^\s]*)?)$`)
+
+// awsIamIdp is a parsed IAM OIDC identity provider ARN of `status.aws_iam_idps`.
+type awsIamIdp struct {
+	key          string
+	arn          string
+	partition    string
+	accountId    string
+	providerHost string
+}
+
+func parseAwsIamIdpArn(key, arn string) (*awsIamIdp, error) {
+	m := awsIamOidcProviderArnRegexp.FindStringSubmatch(arn)
+	if m == nil {
+		return nil, fmt.Errorf("aws_iam_idps[%q] (%q) is not an IAM OIDC provider ARN such as %q", key, arn, "arn:aws:iam::123456789012:oidc-provider/oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE")
+	}
+	return &awsIamIdp{key: key, arn: arn, partition: m[1], accountId: m[2], providerHost: m[3]}, nil
+}
+
+// parseAwsIamIdps parses every value of idps, sorted by key.
+func parseAwsIamIdps(idps map[string]string) ([]*awsIamIdp, error) {
+	keys := make([]string, 0, len(idps))
+	for k := range idps {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]*awsIamIdp, 0, len(keys))
+	for _, k := range keys {
+		idp, err := parseAwsIamIdpArn(k, idps[k])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, idp)
+	}
+	return out, nil
+}
+
+// issuerProviderHost returns the identifier IAM uses for the OIDC provider of issuer, its URL
+// without scheme nor trailing slash.
+func issuerProviderHost(issuer string) (string, error) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(u.Host+u.Path, "/"), nil
+}
+
+func validateAwsIamIdps(value interface{}, key string) (ws []string, es []error) {
+	for k, v := range value.(map[string]interface{}) {
+		if _, err := parseAwsIamIdpArn(k, v.(string)); err != nil {
+			es = append(es, err)
+		}
+	}
+	return
+}
+
+func awsIamIdpEntriesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Parsed entries of `aws_iam_idps`, sorted by key.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"partition": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"account_id": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"provider_host": {
+					Type:        schema.TypeString,
+					Description: "URL of the OIDC provider without scheme, as in the ARN.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+// flattenAwsIamIdpEntries skips values which are not IAM OIDC provider ARNs, as written by other tools.
+func flattenAwsIamIdpEntries(idps map[string]string) []any {
+	keys := make([]string, 0, len(idps))
+	for k := range idps {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	att := []any{}
+	for _, k := range keys {
+		idp, err := parseAwsIamIdpArn(k, idps[k])
+		if err != nil {
+			continue
+		}
+		att = append(att, map[string]any{
+			"key":           idp.key,
+			"partition":     idp.partition,
+			"account_id":    idp.accountId,
+			"provider_host": idp.providerHost,
+		})
+	}
+	return att
+}
+
+// customizeDiffAwsIamIdps fails the plan when an IAM OIDC provider of `status.aws_iam_idps` does
+// not trust `status.service_account_issuer`.
+func customizeDiffAwsIamIdps(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("status.0.service_account_issuer") || !d.NewValueKnown("status.0.aws_iam_idps") {
+		return nil
+	}
+	issuer := d.Get("status.0.service_account_issuer").(string)
+	host, err := issuerProviderHost(issuer)
+	if err != nil || host == "" {
+		return nil
+	}
+
+	idps, err := parseAwsIamIdps(expandStringMap(d.Get("status.0.aws_iam_idps").(map[string]interface{})))
+	if err != nil {
+		return err
+	}
+	var mismatches []string
+	for _, idp := range idps {
+		if idp.providerHost != host {
+			mismatches = append(mismatches, fmt.Sprintf("aws_iam_idps[%q]: %q", idp.key, idp.providerHost))
+		}
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return fmt.Errorf("IAM OIDC providers of status.0.aws_iam_idps must trust the service account issuer %q (%q), found:\n  - %s", issuer, host, strings.Join(mismatches, "\n  - "))
+}
@@ -0,0 +1,112 @@
+package redfox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAwsIamIdpArn(t *testing.T) {
+	cases := []struct {
+		arn      string
+		expected *awsIamIdp
+	}{
+		{
+			arn:      "arn:aws:iam::123456789012:oidc-provider/oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE",
+			expected: &awsIamIdp{partition: "aws", accountId: "123456789012", providerHost: "oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE"},
+		},
+		{
+			arn:      "arn:aws-cn:iam::123456789012:oidc-provider/oidc.eks.cn-north-1.amazonaws.com.cn/id/EXAMPLE",
+			expected: &awsIamIdp{partition: "aws-cn", accountId: "123456789012", providerHost: "oidc.eks.cn-north-1.amazonaws.com.cn/id/EXAMPLE"},
+		},
+		{
+			arn:      "arn:aws-us-gov:iam::123456789012:oidc-provider/oidc.example.com",
+			expected: &awsIamIdp{partition: "aws-us-gov", accountId: "123456789012", providerHost: "oidc.example.com"},
+		},
+		{
+			arn: "arn:aws:iam::123456789012:saml-provider/okta",
+		},
+		{
+			arn: "arn:aws:iam::12345:oidc-provider/oidc.example.com",
+		},
+		{
+			arn: "arn:aws:iam:ap-northeast-2:123456789012:oidc-provider/oidc.example.com",
+		},
+		{
+			arn: "arn:other:iam::123456789012:oidc-provider/oidc.example.com",
+		},
+		{
+			arn: "arn:aws:iam::123456789012:oidc-provider/",
+		},
+		{
+			arn: "arn:aws:iam::123456789012:oidc-provider/oidc.example.com/id/EX AMPLE",
+		},
+		{
+			arn: " arn:aws:iam::123456789012:oidc-provider/oidc.example.com",
+		},
+		{
+			arn: "",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.arn, func(t *testing.T) {
+			idp, err := parseAwsIamIdpArn("dev", tc.arn)
+			if tc.expected == nil {
+				if err == nil || !strings.Contains(err.Error(), `aws_iam_idps["dev"]`) {
+					t.Fatalf("expected an error naming the key, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			tc.expected.key, tc.expected.arn = "dev", tc.arn
+			if !reflect.DeepEqual(idp, tc.expected) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, idp)
+			}
+		})
+	}
+}
+
+func TestIssuerProviderHost(t *testing.T) {
+	cases := map[string]string{
+		"https://oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE":  "oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE",
+		"https://oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE/": "oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE",
+		"https://oidc.example.com":                                  "oidc.example.com",
+		"https://oidc.example.com:8443/":                            "oidc.example.com:8443",
+	}
+	for issuer, expected := range cases {
+		host, err := issuerProviderHost(issuer)
+		if err != nil || host != expected {
+			t.Errorf("issuerProviderHost(%q): expected %q, got %q, %v", issuer, expected, host, err)
+		}
+	}
+}
+
+func TestFlattenAwsIamIdpEntries(t *testing.T) {
+	idps := map[string]string{
+		"prod":    "arn:aws:iam::210987654321:oidc-provider/oidc.example.com/id/PROD",
+		"dev":     "arn:aws:iam::123456789012:oidc-provider/oidc.example.com/id/DEV",
+		"unknown": "written by another tool",
+	}
+	expected := []any{
+		map[string]any{"key": "dev", "partition": "aws", "account_id": "123456789012", "provider_host": "oidc.example.com/id/DEV"},
+		map[string]any{"key": "prod", "partition": "aws", "account_id": "210987654321", "provider_host": "oidc.example.com/id/PROD"},
+	}
+	if entries := flattenAwsIamIdpEntries(idps); !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("expected %v, got %v", expected, entries)
+	}
+	if entries := flattenAwsIamIdpEntries(nil); entries == nil || len(entries) != 0 {
+		t.Fatalf("expected no entries, got %#v", entries)
+	}
+}
+
+func TestValidateAwsIamIdps(t *testing.T) {
+	_, es := validateAwsIamIdps(map[string]interface{}{
+		"dev":  "arn:aws:iam::123456789012:oidc-provider/oidc.example.com/id/DEV",
+		"prod": "arn:aws:iam::123456789012:role/prod",
+	}, "status.0.aws_iam_idps")
+	if len(es) != 1 || !strings.Contains(es[0].Error(), `aws_iam_idps["prod"]`) {
+		t.Fatalf("expected one error for prod, got %v", es)
+	}
+}
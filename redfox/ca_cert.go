@@ -0,0 +1,173 @@
+package redfox
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const defaultCaCertExpiryWarning = 30 * 24 * time.Hour
+
+// caCertPEM returns the PEM of certificate authority data given either as PEM or as base64-encoded
+// PEM, the form of `certificate-authority-data` in kubeconfigs.
+func caCertPEM(data string) ([]byte, error) {
+	raw := []byte(strings.TrimSpace(data))
+	if bytes.HasPrefix(raw, []byte("-----BEGIN")) {
+		return raw, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(raw)), ""))
+	if err != nil {
+		return nil, fmt.Errorf("must be PEM or base64-encoded PEM certificates: %s", err)
+	}
+	return bytes.TrimSpace(decoded), nil
+}
+
+// decodeCaCert parses certificate authority data as accepted by caCertPEM. It fails unless it holds
+// only certificates.
+func decodeCaCert(data string) ([]*x509.Certificate, error) {
+	raw, err := caCertPEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for len(raw) > 0 {
+		block, rest := pem.Decode(raw)
+		if block == nil {
+			if len(bytes.TrimSpace(raw)) > 0 {
+				return nil, fmt.Errorf("contains data which is not PEM encoded")
+			}
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("contains a PEM block of type %q, expected only %q", block.Type, "CERTIFICATE")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("contains an invalid certificate: %s", err)
+		}
+		certs = append(certs, cert)
+		raw = bytes.TrimSpace(rest)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("must contain at least one PEM certificate")
+	}
+	return certs, nil
+}
+
+func validateCaCert(value interface{}, key string) (ws []string, es []error) {
+	if _, err := decodeCaCert(value.(string)); err != nil {
+		es = append(es, fmt.Errorf("%s %s", key, err))
+	}
+	return
+}
+
+// suppressEquivalentCaCert ignores changes between PEM and base64-encoded PEM of the same certificates.
+func suppressEquivalentCaCert(k, old, new string, d *schema.ResourceData) bool {
+	oldCerts, err := decodeCaCert(old)
+	if err != nil {
+		return false
+	}
+	newCerts, err := decodeCaCert(new)
+	if err != nil || len(oldCerts) != len(newCerts) {
+		return false
+	}
+	for i := range oldCerts {
+		if !oldCerts[i].Equal(newCerts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func caCertInfoSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Details of the certificates in `ca_cert`, empty when it cannot be parsed.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"subject": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"issuer": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"not_before": {
+					Type:        schema.TypeString,
+					Description: "Start of the validity period, in RFC 3339 format.",
+					Computed:    true,
+				},
+				"not_after": {
+					Type:        schema.TypeString,
+					Description: "End of the validity period, in RFC 3339 format.",
+					Computed:    true,
+				},
+				"sha256_fingerprint": {
+					Type:        schema.TypeString,
+					Description: "Hex-encoded SHA-256 digest of the DER encoding of the certificate.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+func flattenCaCertInfo(certs []*x509.Certificate) []any {
+	att := make([]any, 0, len(certs))
+	for _, cert := range certs {
+		fingerprint := sha256.Sum256(cert.Raw)
+		att = append(att, map[string]any{
+			"subject":            cert.Subject.String(),
+			"issuer":             cert.Issuer.String(),
+			"not_before":         cert.NotBefore.UTC().Format(time.RFC3339),
+			"not_after":          cert.NotAfter.UTC().Format(time.RFC3339),
+			"sha256_fingerprint": fmt.Sprintf("%x", fingerprint),
+		})
+	}
+	return att
+}
+
+// caCertExpiryDiagnostics warns about certificates of the Cluster `id` expired or expiring within
+// the provider `ca_cert_expiry_warning`.
+func caCertExpiryDiagnostics(id, caCert string, meta interface{}) diag.Diagnostics {
+	window := defaultCaCertExpiryWarning
+	if m, ok := meta.(kubeClientsets); ok {
+		window = m.CaCertExpiryWarning
+	}
+	if window <= 0 || caCert == "" {
+		return nil
+	}
+	certs, err := decodeCaCert(caCert)
+	if err != nil {
+		return nil
+	}
+
+	var diags diag.Diagnostics
+	now := time.Now()
+	for _, cert := range certs {
+		if cert.NotAfter.After(now.Add(window)) {
+			continue
+		}
+		summary := fmt.Sprintf("CA certificate of %s %s expires soon", clusterKind.Kind, id)
+		if cert.NotAfter.Before(now) {
+			summary = fmt.Sprintf("CA certificate of %s %s has expired", clusterKind.Kind, id)
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  summary,
+			Detail:   fmt.Sprintf("Certificate %q is valid until %s. Kubeconfigs built from status.apiserver.ca_cert stop working once it expires.", cert.Subject.String(), cert.NotAfter.UTC().Format(time.RFC3339)),
+		})
+	}
+	return diags
+}
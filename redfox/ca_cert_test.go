@@ -0,0 +1,224 @@
+package redfox
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+func TestDecodeCaCert(t *testing.T) {
+	_, _, rootPem := testCaCert(t, "root")
+	_, _, intermediatePem := testCaCert(t, "intermediate")
+	chain := intermediatePem + rootPem
+	keyPem := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}))
+	invalidPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not DER")}))
+
+	cases := []struct {
+		name     string
+		data     string
+		subjects []string
+		error    string
+	}{
+		{
+			name:     "PEM",
+			data:     rootPem,
+			subjects: []string{"CN=root"},
+		},
+		{
+			name:     "PEM with surrounding spaces",
+			data:     "\n  " + rootPem + "\n\n",
+			subjects: []string{"CN=root"},
+		},
+		{
+			name:     "PEM chain",
+			data:     chain,
+			subjects: []string{"CN=intermediate", "CN=root"},
+		},
+		{
+			name:     "PEM chain with blank lines between certificates",
+			data:     intermediatePem + "\n\n" + rootPem,
+			subjects: []string{"CN=intermediate", "CN=root"},
+		},
+		{
+			name:     "base64-encoded PEM chain",
+			data:     base64.StdEncoding.EncodeToString([]byte(chain)),
+			subjects: []string{"CN=intermediate", "CN=root"},
+		},
+		{
+			name:     "wrapped base64-encoded PEM",
+			data:     testWrap(base64.StdEncoding.EncodeToString([]byte(rootPem)), 64),
+			subjects: []string{"CN=root"},
+		},
+		{
+			name:  "empty",
+			data:  "",
+			error: "must contain at least one PEM certificate",
+		},
+		{
+			name:  "neither PEM nor base64",
+			data:  "not a certificate!",
+			error: "must be PEM or base64-encoded PEM certificates",
+		},
+		{
+			name:  "base64 of something else",
+			data:  base64.StdEncoding.EncodeToString([]byte("not a certificate")),
+			error: "contains data which is not PEM encoded",
+		},
+		{
+			name:  "private key",
+			data:  rootPem + keyPem,
+			error: `contains a PEM block of type "EC PRIVATE KEY"`,
+		},
+		{
+			name:  "trailing data",
+			data:  rootPem + "trailing",
+			error: "contains data which is not PEM encoded",
+		},
+		{
+			name:  "invalid certificate",
+			data:  invalidPem,
+			error: "contains an invalid certificate",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			certs, err := decodeCaCert(tc.data)
+			if tc.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var subjects []string
+			for _, cert := range certs {
+				subjects = append(subjects, cert.Subject.String())
+			}
+			if strings.Join(subjects, ", ") != strings.Join(tc.subjects, ", ") {
+				t.Fatalf("expected certificates %v, got %v", tc.subjects, subjects)
+			}
+		})
+	}
+}
+
+// testWrap breaks s into lines of width characters.
+func testWrap(s string, width int) string {
+	var lines []string
+	for len(s) > width {
+		lines = append(lines, s[:width])
+		s = s[width:]
+	}
+	return strings.Join(append(lines, s), "\n")
+}
+
+func TestSuppressEquivalentCaCert(t *testing.T) {
+	_, _, rootPem := testCaCert(t, "root")
+	_, _, otherPem := testCaCert(t, "other")
+
+	cases := []struct {
+		name     string
+		old      string
+		new      string
+		suppress bool
+	}{
+		{
+			name:     "PEM to base64-encoded PEM",
+			old:      rootPem,
+			new:      base64.StdEncoding.EncodeToString([]byte(rootPem)),
+			suppress: true,
+		},
+		{
+			name: "other certificate",
+			old:  rootPem,
+			new:  otherPem,
+		},
+		{
+			name: "added certificate",
+			old:  rootPem,
+			new:  rootPem + otherPem,
+		},
+		{
+			name: "from empty",
+			new:  rootPem,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if suppress := suppressEquivalentCaCert("ca_cert", tc.old, tc.new, nil); suppress != tc.suppress {
+				t.Fatalf("expected %t, got %t", tc.suppress, suppress)
+			}
+		})
+	}
+}
+
+func TestCaCertExpiryDiagnostics(t *testing.T) {
+	const window = 30 * 24 * time.Hour
+	_, _, validPem := testCaCertValidUntil(t, "valid", time.Now().Add(2*window))
+	_, _, expiringPem := testCaCertValidUntil(t, "expiring", time.Now().Add(window/2))
+	_, _, expiredPem := testCaCertValidUntil(t, "expired", time.Now().Add(-time.Hour))
+
+	cases := []struct {
+		name      string
+		caCert    string
+		window    time.Duration
+		summaries []string
+	}{
+		{
+			name:   "valid",
+			caCert: validPem,
+			window: window,
+		},
+		{
+			name:      "expiring",
+			caCert:    expiringPem,
+			window:    window,
+			summaries: []string{"CA certificate of Cluster default/test expires soon"},
+		},
+		{
+			name:      "expired",
+			caCert:    expiredPem,
+			window:    window,
+			summaries: []string{"CA certificate of Cluster default/test has expired"},
+		},
+		{
+			name:      "chain",
+			caCert:    validPem + expiringPem + expiredPem,
+			window:    window,
+			summaries: []string{"CA certificate of Cluster default/test expires soon", "CA certificate of Cluster default/test has expired"},
+		},
+		{
+			name:      "base64-encoded chain",
+			caCert:    base64.StdEncoding.EncodeToString([]byte(validPem + expiredPem)),
+			window:    window,
+			summaries: []string{"CA certificate of Cluster default/test has expired"},
+		},
+		{
+			name:   "disabled",
+			caCert: expiredPem,
+		},
+		{
+			name:   "invalid",
+			caCert: "not a certificate!",
+			window: window,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := caCertExpiryDiagnostics(defaultNamespace+"/test", tc.caCert, kubeClientsets{CaCertExpiryWarning: tc.window})
+			if len(diags) != len(tc.summaries) {
+				t.Fatalf("expected %d diagnostics, got %v", len(tc.summaries), diags)
+			}
+			for i, d := range diags {
+				if d.Severity != diag.Warning || d.Summary != tc.summaries[i] {
+					t.Errorf("expected a warning %q, got %v %q", tc.summaries[i], d.Severity, d.Summary)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	clusterNameUniquenessNamespace = "namespace"
+	clusterNameUniquenessCluster   = "cluster"
+	clusterNameUniquenessNone      = "none"
+)
+
+// customizeDiffClusterNameUniqueness fails the plan when another Cluster already uses the planned
+// `spec.cluster_name`, within the namespace or the whole cluster depending on the provider
+// `cluster_name_uniqueness`. The plan also fails when Clusters cannot be listed, the check is then
+// skipped by setting `cluster_name_uniqueness` to `none`.
+func customizeDiffClusterNameUniqueness(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	scope := clusterNameUniquenessNamespace
+	if m, ok := meta.(kubeClientsets); ok && m.ClusterNameUniqueness != "" {
+		scope = m.ClusterNameUniqueness
+	}
+	if scope == clusterNameUniquenessNone {
+		return nil
+	}
+	// cluster_name is planned by customizeDiffClusterName from spec.cluster_name or the naming template
+	if !d.NewValueKnown("cluster_name") || !d.NewValueKnown("metadata.0.namespace") {
+		return nil
+	}
+	clusterName := d.Get("cluster_name").(string)
+	if clusterName == "" {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChange("cluster_name") {
+		return nil
+	}
+
+	namespace := d.Get("metadata.0.namespace").(string)
+	self := namespace + "/" + d.Get("metadata.0.name").(string)
+	if d.Id() != "" {
+		self = d.Id()
+	}
+
+	listNamespace := namespace
+	if scope == clusterNameUniquenessCluster {
+		listNamespace = ""
+	}
+	items, err := listClusterMetadataByClusterName(ctx, meta, listNamespace, clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to list %s to check that spec.0.cluster_name %q is unique: %s. Set the provider `cluster_name_uniqueness` to %q to skip the check.", clusterKind.Kind, clusterName, err, clusterNameUniquenessNone)
+	}
+
+	var others []string
+	for _, item := range items {
+		if id := buildId(item); id != self {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	sort.Strings(others)
+	where := fmt.Sprintf("namespace %q", namespace)
+	if scope == clusterNameUniquenessCluster {
+		where = "the cluster"
+	}
+	return fmt.Errorf("spec.0.cluster_name %q is already used in %s by %s %s", clusterName, where, clusterKind.Kind, strings.Join(others, ", "))
+}
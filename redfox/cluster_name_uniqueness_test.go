@@ -0,0 +1,79 @@
+package redfox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	"github.com/krafton-hq/redfox/pkg/generated/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testNamedCluster(namespace, name, clusterName string) *redfoxV1alpha1.Cluster {
+	return &redfoxV1alpha1.Cluster{
+		TypeMeta:   clusterTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       redfoxV1alpha1.ClusterSpec{ClusterName: clusterName},
+	}
+}
+
+func TestCustomizeDiffClusterNameUniqueness(t *testing.T) {
+	forbidden := fake.NewSimpleClientset()
+	forbidden.PrependReactor("list", "clusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(clusterKind.GroupVersion().WithResource("clusters").GroupResource(), "", nil)
+	})
+
+	cases := []struct {
+		name       string
+		client     *fake.Clientset
+		uniqueness string
+		error      string
+	}{
+		{
+			name:   "unique",
+			client: fake.NewSimpleClientset(testNamedCluster(defaultNamespace, "other", "other")),
+		},
+		{
+			name:   "used in the namespace",
+			client: fake.NewSimpleClientset(testNamedCluster(defaultNamespace, "other", "test")),
+			error:  `spec.0.cluster_name "test" is already used in namespace "default" by Cluster default/other`,
+		},
+		{
+			name:   "used in another namespace",
+			client: fake.NewSimpleClientset(testNamedCluster("elsewhere", "other", "test")),
+		},
+		{
+			name:       "used in the cluster",
+			client:     fake.NewSimpleClientset(testNamedCluster("elsewhere", "other", "test")),
+			uniqueness: clusterNameUniquenessCluster,
+			error:      `spec.0.cluster_name "test" is already used in the cluster by Cluster elsewhere/other`,
+		},
+		{
+			name:   "list failure",
+			client: forbidden,
+			error:  "Set the provider `cluster_name_uniqueness` to \"none\" to skip the check.",
+		},
+		{
+			name:       "disabled",
+			client:     forbidden,
+			uniqueness: clusterNameUniquenessNone,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := kubeClientsets{redfoxClient: tc.client, ClusterNameUniqueness: tc.uniqueness}
+			customizeDiff := customdiff.All(customizeDiffClusterName, customizeDiffClusterNameUniqueness)
+			_, err := testResourceDiff(t, resourceRedfoxCluster(), customizeDiff, nil, testClusterConfig(nil), meta)
+			switch {
+			case tc.error == "" && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case tc.error != "" && (err == nil || !strings.Contains(err.Error(), tc.error)):
+				t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+			}
+		})
+	}
+}
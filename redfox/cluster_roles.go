@@ -0,0 +1,98 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	"github.com/samber/lo"
+)
+
+// defaultClusterRoles are the roles known to the redfox API this provider is built against. The
+// provider `allowed_cluster_roles` replaces them for roles added by newer redfox releases.
+var defaultClusterRoles = []string{
+	string(redfoxV1alpha1.ClusterRoleIngame),
+	string(redfoxV1alpha1.ClusterRoleOutgame),
+	string(redfoxV1alpha1.ClusterRoleCentral),
+}
+
+// clusterRolesSchema returns the schema of `spec.roles`. Roles are a set, so their order does not
+// matter. Duplicated and allowed roles are checked by customizeDiffClusterRoles, as allowed roles
+// depend on the provider configuration.
+func clusterRolesSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Roles of the cluster, e.g. `ingame`, `outgame` or `central`.",
+		Type:        schema.TypeSet,
+		Required:    true,
+		Set:         schema.HashString,
+		Elem: &schema.Schema{
+			Type:             schema.TypeString,
+			ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+		},
+	}
+}
+
+func allowedClusterRoles(meta interface{}) []string {
+	if m, ok := meta.(kubeClientsets); ok && len(m.AllowedClusterRoles) > 0 {
+		return m.AllowedClusterRoles
+	}
+	return defaultClusterRoles
+}
+
+// customizeDiffClusterRoles rejects `spec.roles` listing the same role more than once, or roles which
+// are not allowed by the provider configuration.
+func customizeDiffClusterRoles(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if err := checkDuplicatedClusterRoles(d.GetRawConfig()); err != nil {
+		return err
+	}
+	if !d.NewValueKnown("spec.0.roles") {
+		return nil
+	}
+	roles, ok := d.Get("spec.0.roles").(*schema.Set)
+	if !ok {
+		return nil
+	}
+
+	allowed := allowedClusterRoles(meta)
+	var invalid []string
+	for _, role := range roles.List() {
+		if !lo.Contains(allowed, role.(string)) {
+			invalid = append(invalid, fmt.Sprintf("%q", role))
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	sort.Strings(invalid)
+	return fmt.Errorf("spec.0.roles contains unknown roles %s, expected any of %s. Newer roles can be allowed with the provider `allowed_cluster_roles`.", strings.Join(invalid, ", "), strings.Join(allowed, ", "))
+}
+
+// checkDuplicatedClusterRoles rejects roles of raw, the raw configuration, which differ only by case or
+// surrounding spaces, such as `ingame` and `Ingame`. The set hides them, so the raw configuration is
+// checked. Terraform merges identical roles before the provider sees the configuration.
+func checkDuplicatedClusterRoles(raw cty.Value) error {
+	roles, err := cty.GetAttrPath("spec").IndexInt(0).GetAttr("roles").Apply(raw)
+	if err != nil || roles.IsNull() || !roles.IsKnown() || !roles.CanIterateElements() {
+		return nil
+	}
+
+	seen := map[string]string{}
+	for it := roles.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		if !v.IsKnown() || v.IsNull() {
+			continue
+		}
+		role := v.AsString()
+		key := strings.ToLower(strings.TrimSpace(role))
+		if other, ok := seen[key]; ok {
+			return fmt.Errorf("spec.0.roles lists role %s more than once: %q and %q", key, other, role)
+		}
+		seen[key] = role
+	}
+	return nil
+}
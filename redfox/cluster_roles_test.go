@@ -0,0 +1,60 @@
+package redfox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCustomizeDiffClusterRoles(t *testing.T) {
+	cases := []struct {
+		name  string
+		roles []interface{}
+		meta  interface{}
+		error string
+	}{
+		{
+			name:  "valid",
+			roles: []interface{}{"outgame", "ingame"},
+		},
+		{
+			name:  "identical roles are merged",
+			roles: []interface{}{"ingame", "ingame"},
+		},
+		{
+			name:  "duplicated case",
+			roles: []interface{}{"ingame", "Ingame"},
+			error: `spec.0.roles lists role ingame more than once: "Ingame" and "ingame"`,
+		},
+		{
+			name:  "duplicated spaces",
+			roles: []interface{}{"central", " central"},
+			error: `spec.0.roles lists role central more than once: " central" and "central"`,
+		},
+		{
+			name:  "unknown role",
+			roles: []interface{}{"ingame", "lobby"},
+			error: `spec.0.roles contains unknown roles "lobby"`,
+		},
+		{
+			name:  "allowed by the provider",
+			roles: []interface{}{"lobby"},
+			meta:  kubeClientsets{AllowedClusterRoles: []string{"lobby"}},
+		},
+		{
+			name:  "unknown role value",
+			roles: []interface{}{"ingame", testUnknown},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testClusterConfig(map[string]interface{}{"roles": tc.roles})
+			_, err := testResourceDiff(t, resourceRedfoxCluster(), customizeDiffClusterRoles, nil, config, tc.meta)
+			switch {
+			case tc.error == "" && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case tc.error != "" && (err == nil || !strings.Contains(err.Error(), tc.error)):
+				t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+			}
+		})
+	}
+}
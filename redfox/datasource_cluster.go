@@ -6,7 +6,6 @@
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
-	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -15,7 +14,9 @@ func dataSourceRedfoxCluster() *schema.Resource {
 		ReadContext: dataSourceRedfoxClusterRead,
 
 		Schema: map[string]*schema.Schema{
-			"metadata": namespacedMetadataSchema("cluster", false),
+			"resource_version": resourceVersionSchema("cluster"),
+			"generation":       generationSchema("cluster"),
+			"metadata":         namespacedMetadataSchema("cluster", false),
 			"spec": {
 				Type:        schema.TypeList,
 				Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
@@ -70,15 +71,7 @@ func dataSourceRedfoxCluster() *schema.Resource {
 							Required:         true,
 							ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
 						},
-						"roles": {
-							Description: "",
-							Type:        schema.TypeList,
-							Required:    true,
-							Elem: &schema.Schema{
-								Type:             schema.TypeString,
-								ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{string(redfoxV1alpha1.ClusterRoleIngame), string(redfoxV1alpha1.ClusterRoleOutgame), string(redfoxV1alpha1.ClusterRoleCentral)}, false)),
-							},
-						},
+						"roles": clusterRolesSchema(),
 						"vpc_id": {
 							Description:      "",
 							Type:             schema.TypeString,
@@ -97,50 +90,7 @@ func dataSourceRedfoxCluster() *schema.Resource {
 					},
 				},
 			},
-			"status": {
-				Type:        schema.TypeList,
-				Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
-				Computed:    true,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"apiserver": {
-							Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
-							Type:        schema.TypeList,
-							Required:    true,
-							MinItems:    1,
-							MaxItems:    1,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"endpoint": {
-										Description:      "",
-										Type:             schema.TypeString,
-										Required:         true,
-										ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
-									},
-									"ca_cert": {
-										Description:      "",
-										Type:             schema.TypeString,
-										Required:         true,
-										ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
-									},
-								},
-							},
-						},
-						"service_account_issuer": {
-							Description:      "",
-							Type:             schema.TypeString,
-							Required:         true,
-							ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
-						},
-						"aws_iam_idps": {
-							Description: "",
-							Type:        schema.TypeMap,
-							Optional:    true,
-							Elem:        &schema.Schema{Type: schema.TypeString},
-						},
-					},
-				},
-			},
+			"status": clusterStatusComputedSchema(),
 		},
 	}
 }
@@ -152,10 +102,10 @@ func dataSourceRedfoxClusterRead(ctx context.Context, d *schema.ResourceData, me
 	}
 	d.SetId(buildId(om))
 
-	diagnostic := resourceRedfoxClusterRead(ctx, d, meta)
+	diagnostic := readRedfoxCluster(ctx, d, meta, true)
 	if diagnostic != nil {
 		return diagnostic
 	}
 
-	return resourceRedfoxClusterStatusRead(ctx, d, meta)
+	return readRedfoxClusterStatus(ctx, d, meta, true)
 }
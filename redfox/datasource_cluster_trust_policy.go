@@ -0,0 +1,225 @@
+package redfox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultTrustPolicyAudience = "sts.amazonaws.com"
+
+func dataSourceRedfoxClusterTrustPolicy() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRedfoxClusterTrustPolicyRead,
+
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeList,
+				Description: "Cluster whose `status.service_account_issuer` and `status.aws_iam_idps` are trusted.",
+				Required:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Description:  "Name of the cluster.",
+							Required:     true,
+							ValidateFunc: validateName,
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Description: "Namespace of the cluster.",
+							Optional:    true,
+							Default:     defaultNamespace,
+						},
+					},
+				},
+			},
+			"service_account": {
+				Type:        schema.TypeList,
+				Description: "Service accounts allowed to assume the role. `*` and `?` wildcards are allowed in both fields.",
+				Required:    true,
+				MinItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"namespace": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+						},
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+						},
+					},
+				},
+			},
+			"audiences": {
+				Type:        schema.TypeList,
+				Description: fmt.Sprintf("Audiences of the service account tokens. Defaults to `%s`.", defaultTrustPolicyAudience),
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+			},
+			"policies": {
+				Type:        schema.TypeList,
+				Description: "Trust policy of every AWS account in `status.aws_iam_idps`, sorted by account ID.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"account_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"provider_arn": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"json": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"json": {
+				Type:        schema.TypeMap,
+				Description: "Trust policy JSON documents keyed by AWS account ID.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+type trustPolicyDocument struct {
+	Version   string                 `json:"Version"`
+	Statement []trustPolicyStatement `json:"Statement"`
+}
+
+type trustPolicyStatement struct {
+	Effect    string                         `json:"Effect"`
+	Principal map[string]string              `json:"Principal"`
+	Action    string                         `json:"Action"`
+	Condition map[string]map[string][]string `json:"Condition"`
+}
+
+// renderTrustPolicy renders the trust policy of the IAM OIDC provider idp for subjects and audiences.
+// Subjects are compared with StringLike as soon as one of them has a wildcard, as conditions on the
+// same key are combined with AND across operators.
+func renderTrustPolicy(idp *awsIamIdp, subjects, audiences []string) (string, error) {
+	subjects = append([]string{}, subjects...)
+	audiences = append([]string{}, audiences...)
+	sort.Strings(subjects)
+	sort.Strings(audiences)
+
+	subjectOperator := "StringEquals"
+	for _, s := range subjects {
+		if strings.ContainsAny(s, "*?") {
+			subjectOperator = "StringLike"
+			break
+		}
+	}
+	condition := map[string]map[string][]string{
+		"StringEquals": {idp.providerHost + ":aud": audiences},
+	}
+	if _, ok := condition[subjectOperator]; !ok {
+		condition[subjectOperator] = map[string][]string{}
+	}
+	condition[subjectOperator][idp.providerHost+":sub"] = subjects
+
+	buf, err := json.Marshal(trustPolicyDocument{
+		Version: "2012-10-17",
+		Statement: []trustPolicyStatement{{
+			Effect:    "Allow",
+			Principal: map[string]string{"Federated": idp.arn},
+			Action:    "sts:AssumeRoleWithWebIdentity",
+			Condition: condition,
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func dataSourceRedfoxClusterTrustPolicyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	conn, err := meta.(KubeClientsets).RedfoxClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ref := d.Get("cluster").([]interface{})[0].(map[string]interface{})
+	namespace, name := ref["namespace"].(string), ref["name"].(string)
+
+	tflog.Info(ctx, fmt.Sprintf("Reading %s %s/%s", clusterKind.Kind, namespace, name))
+	cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
+	}
+
+	if len(cluster.Status.AwsIamIdps) == 0 {
+		return diag.Errorf("%s %s/%s has no status.aws_iam_idps, no trust policy can be rendered.", clusterKind.Kind, namespace, name)
+	}
+	idps, err := parseAwsIamIdps(cluster.Status.AwsIamIdps)
+	if err != nil {
+		return diag.Errorf("%s %s/%s has an invalid status: %s", clusterKind.Kind, namespace, name, err)
+	}
+
+	var subjects []string
+	for _, raw := range d.Get("service_account").([]interface{}) {
+		sa := raw.(map[string]interface{})
+		subjects = append(subjects, fmt.Sprintf("system:serviceaccount:%s:%s", sa["namespace"], sa["name"]))
+	}
+	subjects = lo.Uniq[string](subjects)
+	audiences := lo.Uniq[string](expandStringSlice(d.Get("audiences").([]interface{})))
+	if len(audiences) == 0 {
+		audiences = []string{defaultTrustPolicyAudience}
+	}
+
+	// The providers of an account all trust the same issuer, the first one by key is used
+	sort.SliceStable(idps, func(i, j int) bool { return idps[i].accountId < idps[j].accountId })
+	policies := []any{}
+	documents := map[string]any{}
+	for _, idp := range idps {
+		if _, ok := documents[idp.accountId]; ok {
+			continue
+		}
+		document, err := renderTrustPolicy(idp, subjects, audiences)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		documents[idp.accountId] = document
+		policies = append(policies, map[string]any{
+			"account_id":   idp.accountId,
+			"provider_arn": idp.arn,
+			"json":         document,
+		})
+	}
+
+	if err := d.Set("policies", policies); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("json", documents); err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := hashTerraformObjects(policies)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+	return nil
+}
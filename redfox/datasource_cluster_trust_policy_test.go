@@ -0,0 +1,72 @@
+package redfox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderTrustPolicy(t *testing.T) {
+	idp := &awsIamIdp{
+		key:          "dev",
+		arn:          "arn:aws:iam::123456789012:oidc-provider/oidc.example.com/id/DEV",
+		partition:    "aws",
+		accountId:    "123456789012",
+		providerHost: "oidc.example.com/id/DEV",
+	}
+	const (
+		header = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/oidc.example.com/id/DEV"},"Action":"sts:AssumeRoleWithWebIdentity","Condition":`
+		footer = `}]}`
+	)
+
+	cases := []struct {
+		name      string
+		subjects  []string
+		audiences []string
+		expected  string
+	}{
+		{
+			name:      "exact subjects",
+			subjects:  []string{"system:serviceaccount:game:server", "system:serviceaccount:game:api"},
+			audiences: []string{"sts.amazonaws.com"},
+			expected:  header + `{"StringEquals":{"oidc.example.com/id/DEV:aud":["sts.amazonaws.com"],"oidc.example.com/id/DEV:sub":["system:serviceaccount:game:api","system:serviceaccount:game:server"]}}` + footer,
+		},
+		{
+			name:      "wildcard subject",
+			subjects:  []string{"system:serviceaccount:game:server", "system:serviceaccount:batch:*"},
+			audiences: []string{"sts.amazonaws.com", "api.example.com"},
+			expected:  header + `{"StringEquals":{"oidc.example.com/id/DEV:aud":["api.example.com","sts.amazonaws.com"]},"StringLike":{"oidc.example.com/id/DEV:sub":["system:serviceaccount:batch:*","system:serviceaccount:game:server"]}}` + footer,
+		},
+		{
+			name:      "single character wildcard subject",
+			subjects:  []string{"system:serviceaccount:game:worker-?"},
+			audiences: []string{"sts.amazonaws.com"},
+			expected:  header + `{"StringEquals":{"oidc.example.com/id/DEV:aud":["sts.amazonaws.com"]},"StringLike":{"oidc.example.com/id/DEV:sub":["system:serviceaccount:game:worker-?"]}}` + footer,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			subjects := append([]string{}, tc.subjects...)
+			audiences := append([]string{}, tc.audiences...)
+			for i := 0; i < 3; i++ {
+				policy, err := renderTrustPolicy(idp, subjects, audiences)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if policy != tc.expected {
+					t.Fatalf("expected\n%s\ngot\n%s", tc.expected, policy)
+				}
+			}
+			if !reflect.DeepEqual(subjects, tc.subjects) || !reflect.DeepEqual(audiences, tc.audiences) {
+				t.Fatalf("expected the subjects and audiences to be left unsorted, got %v and %v", subjects, audiences)
+			}
+
+			reversed := make([]string, len(subjects))
+			for i, s := range subjects {
+				reversed[len(subjects)-1-i] = s
+			}
+			if policy, _ := renderTrustPolicy(idp, reversed, audiences); policy != tc.expected {
+				t.Fatalf("expected the same policy for subjects in another order, got\n%s", policy)
+			}
+		})
+	}
+}
@@ -8,7 +8,6 @@
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
-	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -93,15 +92,7 @@ func dataSourceRedfoxClusters() *schema.Resource {
 										Required:         true,
 										ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
 									},
-									"roles": {
-										Description: "",
-										Type:        schema.TypeList,
-										Required:    true,
-										Elem: &schema.Schema{
-											Type:             schema.TypeString,
-											ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{string(redfoxV1alpha1.ClusterRoleIngame), string(redfoxV1alpha1.ClusterRoleOutgame), string(redfoxV1alpha1.ClusterRoleCentral)}, false)),
-										},
-									},
+									"roles": clusterRolesSchema(),
 									"vpc_id": {
 										Description:      "",
 										Type:             schema.TypeString,
@@ -120,50 +111,7 @@ func dataSourceRedfoxClusters() *schema.Resource {
 								},
 							},
 						},
-						"status": {
-							Type:        schema.TypeList,
-							Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
-							Computed:    true,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"apiserver": {
-										Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
-										Type:        schema.TypeList,
-										Required:    true,
-										MinItems:    1,
-										MaxItems:    1,
-										Elem: &schema.Resource{
-											Schema: map[string]*schema.Schema{
-												"endpoint": {
-													Description:      "",
-													Type:             schema.TypeString,
-													Required:         true,
-													ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
-												},
-												"ca_cert": {
-													Description:      "",
-													Type:             schema.TypeString,
-													Required:         true,
-													ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
-												},
-											},
-										},
-									},
-									"service_account_issuer": {
-										Description:      "",
-										Type:             schema.TypeString,
-										Required:         true,
-										ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
-									},
-									"aws_iam_idps": {
-										Description: "",
-										Type:        schema.TypeMap,
-										Optional:    true,
-										Elem:        &schema.Schema{Type: schema.TypeString},
-									},
-								},
-							},
-						},
+						"status": clusterStatusComputedSchema(),
 					},
 				},
 			},
@@ -189,10 +137,11 @@ func dataSourceRedfoxClustersRead(ctx context.Context, d *schema.ResourceData, m
 		LabelSelector: kubeGenericSelector.String(),
 	})
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "list", clusterKind.Kind, nil)
 	}
 
 	var attrs []any
+	var diags diag.Diagnostics
 	for index, cluster := range outs.Items {
 		att := map[string]any{}
 		att["metadata"] = flattenMetadata(cluster.ObjectMeta, d, meta, fmt.Sprintf("items.%d.", index))
@@ -208,6 +157,7 @@ func dataSourceRedfoxClustersRead(ctx context.Context, d *schema.ResourceData, m
 			return diag.FromErr(err)
 		}
 		att["status"] = status
+		diags = append(diags, caCertExpiryDiagnostics(buildId(cluster.ObjectMeta), cluster.Status.Apiserver.CaCert, meta)...)
 
 		attrs = append(attrs, att)
 	}
@@ -222,5 +172,5 @@ func dataSourceRedfoxClustersRead(ctx context.Context, d *schema.ResourceData, m
 	}
 	d.SetId(hashId)
 
-	return nil
+	return diags
 }
@@ -0,0 +1,299 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const defaultKubeconfigExecApiVersion = "client.authentication.k8s.io/v1beta1"
+
+func dataSourceRedfoxKubeconfig() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRedfoxKubeconfigRead,
+
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:          schema.TypeList,
+				Description:   "Single cluster to render. Conflicts with `namespace` and `selector`.",
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"namespace", "selector"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Description:  "Name of the cluster.",
+							Required:     true,
+							ValidateFunc: validateName,
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Description: "Namespace of the cluster.",
+							Optional:    true,
+							Default:     defaultNamespace,
+						},
+					},
+				},
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Description: "Namespace of the clusters to render, all namespaces when empty.",
+				Optional:    true,
+			},
+			"selector": {
+				Type:        schema.TypeList,
+				Description: "Label selector of the clusters to render.",
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: labelSelectorFields(true),
+				},
+			},
+			"exec": {
+				Type:        schema.TypeList,
+				Description: "Exec credential plugin of the user of every context. `command`, `args` and `env` values are Go templates given `.ClusterName`, `.Name`, `.Namespace`, `.Endpoint` and the other spec attributes of the cluster, e.g. `.ClusterRegion` or `.InfraAccountId`.",
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"api_version": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  defaultKubeconfigExecApiVersion,
+						},
+						"command": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateNamingTemplate,
+						},
+						"args": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateNamingTemplate,
+							},
+						},
+						"env": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+							ValidateDiagFunc: validateEachMapValue(validateNamingTemplate),
+						},
+					},
+				},
+			},
+			"contexts": {
+				Type:        schema.TypeList,
+				Description: "Names of the contexts of the kubeconfig, sorted. Every context, cluster and user is named after `spec.cluster_name`.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"raw": {
+				Type:        schema.TypeString,
+				Description: "Kubeconfig YAML. `current-context` is only set when a single cluster is rendered.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// kubeconfigExecData is the data given to the `exec` templates of redfox_kubeconfig.
+type kubeconfigExecData struct {
+	clusterNamingData
+	ClusterName string
+	Name        string
+	Endpoint    string
+}
+
+func newKubeconfigExecData(cluster *redfoxV1alpha1.Cluster) *kubeconfigExecData {
+	return &kubeconfigExecData{
+		clusterNamingData: clusterNamingData{
+			Namespace:      cluster.Namespace,
+			ClusterGroup:   cluster.Spec.ClusterGroup,
+			ClusterEngine:  cluster.Spec.ClusterEngine,
+			ClusterRegion:  cluster.Spec.ClusterRegion,
+			InfraVendor:    cluster.Spec.InfraVendor,
+			InfraAccountId: cluster.Spec.InfraAccountId,
+			ServicePhase:   cluster.Spec.ServicePhase,
+			ServiceTag:     cluster.Spec.ServiceTag,
+		},
+		ClusterName: kubeconfigEntryName(cluster),
+		Name:        cluster.Name,
+		Endpoint:    cluster.Status.Apiserver.Endpoint,
+	}
+}
+
+func kubeconfigEntryName(cluster *redfoxV1alpha1.Cluster) string {
+	if cluster.Spec.ClusterName != "" {
+		return cluster.Spec.ClusterName
+	}
+	return cluster.Name
+}
+
+// expandKubeconfigExec renders the `exec` block of redfox_kubeconfig for cluster.
+func expandKubeconfigExec(l []interface{}, cluster *redfoxV1alpha1.Cluster) (*clientcmdapi.ExecConfig, error) {
+	if len(l) == 0 || l[0] == nil {
+		return nil, nil
+	}
+	in := l[0].(map[string]interface{})
+	data := newKubeconfigExecData(cluster)
+
+	exec := &clientcmdapi.ExecConfig{
+		APIVersion:      in["api_version"].(string),
+		InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+	}
+	var err error
+	if exec.Command, err = renderNamingTemplate("exec.0.command", in["command"].(string), data); err != nil {
+		return nil, err
+	}
+	for i, arg := range expandStringSlice(in["args"].([]interface{})) {
+		rendered, err := renderNamingTemplate(fmt.Sprintf("exec.0.args.%d", i), arg, data)
+		if err != nil {
+			return nil, err
+		}
+		exec.Args = append(exec.Args, rendered)
+	}
+	env := expandStringMap(in["env"].(map[string]interface{}))
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rendered, err := renderNamingTemplate("exec.0.env."+name, env[name], data)
+		if err != nil {
+			return nil, err
+		}
+		exec.Env = append(exec.Env, clientcmdapi.ExecEnvVar{Name: name, Value: rendered})
+	}
+	return exec, nil
+}
+
+func listKubeconfigClusters(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]redfoxV1alpha1.Cluster, diag.Diagnostics) {
+	conn, err := meta.(KubeClientsets).RedfoxClient()
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	if v, ok := d.GetOk("cluster"); ok {
+		ref := v.([]interface{})[0].(map[string]interface{})
+		namespace, name := ref["namespace"].(string), ref["name"].(string)
+		tflog.Info(ctx, fmt.Sprintf("Reading %s %s/%s", clusterKind.Kind, namespace, name))
+		cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
+		}
+		if cluster.Status.Apiserver.Endpoint == "" {
+			return nil, diag.Errorf("%s %s/%s has no status.apiserver.endpoint, no kubeconfig can be rendered.", clusterKind.Kind, namespace, name)
+		}
+		return []redfoxV1alpha1.Cluster{*cluster}, nil
+	}
+
+	labelSelector := expandLabelSelector(d.Get("selector").([]any))
+	kubeGenericSelector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	outs, err := conn.MetadataV1alpha1().Clusters(d.Get("namespace").(string)).List(ctx, metav1.ListOptions{
+		LabelSelector: kubeGenericSelector.String(),
+	})
+	if err != nil {
+		return nil, kubeErrorDiagnostics(err, "list", clusterKind.Kind, nil)
+	}
+	return outs.Items, nil
+}
+
+func dataSourceRedfoxKubeconfigRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	clusters, diags := listKubeconfigClusters(ctx, d, meta)
+	if diags.HasError() {
+		return diags
+	}
+
+	config := clientcmdapi.NewConfig()
+	owners := map[string]string{}
+	for i := range clusters {
+		cluster := &clusters[i]
+		id := buildId(cluster.ObjectMeta)
+		if cluster.Status.Apiserver.Endpoint == "" {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("%s %s is left out of the kubeconfig", clusterKind.Kind, id),
+				Detail:   "It has no status.apiserver.endpoint yet.",
+			})
+			continue
+		}
+
+		name := kubeconfigEntryName(cluster)
+		if owner, ok := owners[name]; ok {
+			return append(diags, diag.Errorf("%s %s and %s share the cluster name %q, which names their kubeconfig context. Narrow `namespace` or `selector`.", clusterKind.Kind, owner, id, name)...)
+		}
+		owners[name] = id
+
+		entry := clientcmdapi.NewCluster()
+		entry.Server = cluster.Status.Apiserver.Endpoint
+		if caCert := cluster.Status.Apiserver.CaCert; caCert != "" {
+			if _, err := decodeCaCert(caCert); err != nil {
+				return append(diags, diag.Errorf("%s %s has an invalid status.apiserver.ca_cert: %s", clusterKind.Kind, id, err)...)
+			}
+			pem, _ := caCertPEM(caCert)
+			entry.CertificateAuthorityData = append(pem, '\n')
+		}
+		config.Clusters[name] = entry
+
+		kubeContext := clientcmdapi.NewContext()
+		kubeContext.Cluster = name
+		exec, err := expandKubeconfigExec(d.Get("exec").([]interface{}), cluster)
+		if err != nil {
+			return append(diags, diag.Errorf("failed to render `exec` for %s %s: %s", clusterKind.Kind, id, err)...)
+		}
+		if exec != nil {
+			user := clientcmdapi.NewAuthInfo()
+			user.Exec = exec
+			config.AuthInfos[name] = user
+			kubeContext.AuthInfo = name
+		}
+		config.Contexts[name] = kubeContext
+
+		diags = append(diags, caCertExpiryDiagnostics(id, cluster.Status.Apiserver.CaCert, meta)...)
+	}
+
+	contexts := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	if len(contexts) == 1 {
+		config.CurrentContext = contexts[0]
+	}
+
+	raw, err := clientcmd.Write(*config)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	if err := d.Set("contexts", contexts); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if err := d.Set("raw", string(raw)); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	id, err := hashTerraformObjects(string(raw))
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	d.SetId(id)
+	return diags
+}
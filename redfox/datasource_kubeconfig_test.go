@@ -0,0 +1,120 @@
+package redfox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	"github.com/krafton-hq/redfox/pkg/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestKubeconfigExecEnvValidation(t *testing.T) {
+	r := dataSourceRedfoxKubeconfig()
+	exec := func(env map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"exec": []interface{}{map[string]interface{}{"command": "aws", "env": env}},
+		}
+	}
+	if diags := r.Validate(testResourceConfig(t, r, exec(map[string]interface{}{"AWS_REGION": "{{.ClusterRegion}}"}))); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	diags := r.Validate(testResourceConfig(t, r, exec(map[string]interface{}{"AWS_REGION": "{{.ClusterRegion}}", "AWS_PROFILE": "{{.InfraAccountId"})))
+	if len(diags) != 1 || !diags.HasError() {
+		t.Fatalf("expected the invalid env template to be rejected, got %v", diags)
+	}
+}
+
+func testKubeconfigCluster(name, clusterName, region, endpoint string) *redfoxV1alpha1.Cluster {
+	return &redfoxV1alpha1.Cluster{
+		TypeMeta:   clusterTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Namespace: "redfox-metadata", Name: name},
+		Spec:       redfoxV1alpha1.ClusterSpec{ClusterName: clusterName, ClusterRegion: region},
+		Status:     redfoxV1alpha1.ClusterStatus{Apiserver: redfoxV1alpha1.ApiserverInfo{Endpoint: endpoint}},
+	}
+}
+
+const testKubeconfigRaw = `apiVersion: v1
+clusters:
+- cluster:
+    server: https://a.example.com
+  name: dev-a
+- cluster:
+    server: https://b.example.com
+  name: dev-b
+contexts:
+- context:
+    cluster: dev-a
+    user: dev-a
+  name: dev-a
+- context:
+    cluster: dev-b
+    user: dev-b
+  name: dev-b
+current-context: ""
+kind: Config
+preferences: {}
+users:
+- name: dev-a
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      args:
+      - eks
+      - get-token
+      - --cluster-name
+      - dev-a
+      command: aws
+      env:
+      - name: AWS_PROFILE
+        value: dev
+      - name: AWS_REGION
+        value: ap-northeast-2
+      interactiveMode: IfAvailable
+      provideClusterInfo: false
+- name: dev-b
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      args:
+      - eks
+      - get-token
+      - --cluster-name
+      - dev-b
+      command: aws
+      env:
+      - name: AWS_PROFILE
+        value: dev
+      - name: AWS_REGION
+        value: us-east-1
+      interactiveMode: IfAvailable
+      provideClusterInfo: false
+`
+
+func TestKubeconfigIsStable(t *testing.T) {
+	a := testKubeconfigCluster("a", "dev-a", "ap-northeast-2", "https://a.example.com")
+	b := testKubeconfigCluster("b", "dev-b", "us-east-1", "https://b.example.com")
+	config := map[string]interface{}{
+		"namespace": "redfox-metadata",
+		"exec": []interface{}{map[string]interface{}{
+			"command": "aws",
+			"args":    []interface{}{"eks", "get-token", "--cluster-name", "{{.ClusterName}}"},
+			"env":     map[string]interface{}{"AWS_REGION": "{{.ClusterRegion}}", "AWS_PROFILE": "dev"},
+		}},
+	}
+
+	for _, objects := range [][]runtime.Object{{a, b}, {b, a}} {
+		for i := 0; i < 3; i++ {
+			d := schema.TestResourceDataRaw(t, dataSourceRedfoxKubeconfig().Schema, config)
+			meta := kubeClientsets{redfoxClient: fake.NewSimpleClientset(objects...)}
+			if diags := dataSourceRedfoxKubeconfigRead(context.Background(), d, meta); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if raw := d.Get("raw").(string); raw != testKubeconfigRaw {
+				t.Fatalf("unexpected kubeconfig:\n%s", raw)
+			}
+		}
+	}
+}
@@ -15,7 +15,9 @@ func dataSourceRedfoxNatIp() *schema.Resource {
 		ReadContext: dataSourceRedfoxNatIpRead,
 
 		Schema: map[string]*schema.Schema{
-			"metadata": namespacedMetadataSchema("natip", false),
+			"resource_version": resourceVersionSchema("natip"),
+			"generation":       generationSchema("natip"),
+			"metadata":         namespacedMetadataSchema("natip", false),
 			"spec": {
 				Type:        schema.TypeList,
 				Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
@@ -37,6 +39,8 @@ func dataSourceRedfoxNatIp() *schema.Resource {
 								ValidateDiagFunc: validation.ToDiagFunc(validation.IsCIDR),
 							},
 						},
+						"ipv4_cidrs": natIpCidrsByTypeSchema(redfoxV1alpha1.Ipv4),
+						"ipv6_cidrs": natIpCidrsByTypeSchema(redfoxV1alpha1.Ipv6),
 					},
 				},
 			},
@@ -51,5 +55,5 @@ func dataSourceRedfoxNatIpRead(ctx context.Context, d *schema.ResourceData, meta
 	}
 	d.SetId(buildId(om))
 
-	return resourceRedfoxNatIpRead(ctx, d, meta)
+	return readRedfoxNatIp(ctx, d, meta, true)
 }
@@ -62,6 +62,8 @@ func dataSourceRedfoxNatIps() *schema.Resource {
 											ValidateDiagFunc: validation.ToDiagFunc(validation.IsCIDR),
 										},
 									},
+									"ipv4_cidrs": natIpCidrsByTypeSchema(redfoxV1alpha1.Ipv4),
+									"ipv6_cidrs": natIpCidrsByTypeSchema(redfoxV1alpha1.Ipv6),
 								},
 							},
 						},
@@ -90,7 +92,7 @@ func dataSourceRedfoxNatIpsRead(ctx context.Context, d *schema.ResourceData, met
 		LabelSelector: kubeGenericSelector.String(),
 	})
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "list", natipKind.Kind, nil)
 	}
 
 	var attrs []any
@@ -102,7 +104,7 @@ func dataSourceRedfoxNatIpsRead(ctx context.Context, d *schema.ResourceData, met
 		if err != nil {
 			return diag.FromErr(err)
 		}
-		att["spec"] = spec
+		att["spec"] = flattenNatIpCidrsByType(spec, natIp.Spec)
 
 		attrs = append(attrs, att)
 	}
@@ -0,0 +1,200 @@
+package redfox
+
+import (
+	goerrors "errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// kubeErrorDiagnostics translates an error returned by the API server while trying to `action` an
+// object of `kind` into diagnostics. Causes reported by the API server are attached to the matching
+// attribute of resourceSchema, which may be nil when the error does not relate to configuration.
+// Errors wrapping an API status are unwrapped, and retries running out of time are reported as
+// timeouts.
+func kubeErrorDiagnostics(err error, action, kind string, resourceSchema map[string]*schema.Schema) diag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	var timeoutErr *resource.TimeoutError
+	if goerrors.As(err, &timeoutErr) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Timed out trying to %s %s", action, kind),
+			Detail:   fmt.Sprintf("%s\n\n%s", err.Error(), kubeTimeoutHint),
+		}}
+	}
+
+	var apiStatus errors.APIStatus
+	if !goerrors.As(err, &apiStatus) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Failed to %s %s", action, kind),
+			Detail:   err.Error(),
+		}}
+	}
+	status := apiStatus.Status()
+
+	var summary, hint string
+	switch {
+	case errors.IsInvalid(err):
+		summary = fmt.Sprintf("Invalid %s rejected by the API server", kind)
+	case errors.IsForbidden(err):
+		summary = fmt.Sprintf("Not allowed to %s %s", action, kind)
+		hint = "Check the RBAC permissions of the credentials configured for the provider."
+	case errors.IsConflict(err):
+		summary = fmt.Sprintf("Conflict while trying to %s %s", action, kind)
+		hint = "The object was modified concurrently or some of its fields are owned by another field manager."
+	case errors.IsNotFound(err):
+		summary = fmt.Sprintf("Failed to %s %s: not found", action, kind)
+		hint = "The object, its namespace or the CustomResourceDefinition may not exist yet."
+	case errors.IsTimeout(err), errors.IsServerTimeout(err):
+		summary = fmt.Sprintf("Timed out trying to %s %s", action, kind)
+		hint = kubeTimeoutHint
+	default:
+		summary = fmt.Sprintf("Failed to %s %s: %s", action, kind, status.Reason)
+	}
+
+	detail := status.Message
+	if hint != "" {
+		detail = strings.TrimSpace(detail + "\n\n" + hint)
+	}
+
+	var causes []metav1.StatusCause
+	if status.Details != nil {
+		causes = status.Details.Causes
+	}
+	if len(causes) == 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   detail,
+		}}
+	}
+
+	var diags diag.Diagnostics
+	for _, cause := range causes {
+		d := diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   cause.Message,
+		}
+		if cause.Field != "" {
+			d.Summary = fmt.Sprintf("%s: %s", summary, strings.TrimPrefix(cause.Field, "."))
+			d.AttributePath = kubeFieldToAttributePath(cause.Field, resourceSchema)
+		}
+		if hint != "" {
+			d.Detail = strings.TrimSpace(d.Detail + "\n\n" + hint)
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+const kubeTimeoutHint = "The API server did not respond in time. Retry or increase the resource timeouts."
+
+var kubeFieldPathTokenRegexp = regexp.MustCompile(`([^.\[\]]+)|\[([^\]]*)\]`)
+
+// kubeFieldToAttributePath converts a Kubernetes field path such as `spec.cidrs[2]` into the
+// Terraform attribute path `spec.0.cidrs.2` by walking resourceSchema. The path stops at the
+// deepest attribute found, and is nil when not even the first segment is known.
+func kubeFieldToAttributePath(field string, resourceSchema map[string]*schema.Schema) cty.Path {
+	current := resourceSchema
+	var path cty.Path
+	// s is the schema of the last attribute in path, nil once path reached a primitive value
+	var s *schema.Schema
+	indexed := false
+
+	for _, token := range kubeFieldPathTokenRegexp.FindAllStringSubmatch(strings.TrimPrefix(field, "."), -1) {
+		if token[1] == "" {
+			if s == nil {
+				return path
+			}
+			switch s.Type {
+			case schema.TypeMap:
+				path = path.IndexString(token[2])
+				s = nil
+			case schema.TypeList:
+				index, err := strconv.Atoi(token[2])
+				if err != nil {
+					return path
+				}
+				path = path.IndexInt(index)
+				if _, ok := s.Elem.(*schema.Resource); !ok {
+					s = nil
+				}
+				indexed = true
+			default:
+				return path
+			}
+			continue
+		}
+
+		next := path
+		if len(path) > 0 {
+			if s == nil {
+				return path
+			}
+			elem, ok := s.Elem.(*schema.Resource)
+			if !ok {
+				return path
+			}
+			// Single nested blocks are lists of one element in Terraform
+			if !indexed {
+				next = next.IndexInt(0)
+			}
+			current = elem.Schema
+		}
+
+		attribute := camelToSnake(token[1])
+		attributeSchema, ok := current[attribute]
+		if !ok {
+			return path
+		}
+		path = next.GetAttr(attribute)
+		s = attributeSchema
+		indexed = false
+	}
+	return path
+}
+
+// camelToSnake converts Kubernetes JSON field names such as `infraAccountId` to `infra_account_id`.
+// Acronyms are kept in one word, also when pluralized: `awsIamIDPs` converts to `aws_iam_idps`.
+func camelToSnake(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && startsWord(runes, i) {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// startsWord reports whether the uppercase rune at i starts a word: after a lowercase letter or a
+// digit, or as the last capital of an acronym followed by a lowercase word, as `S` in `HTTPServer`.
+// A single `s` pluralizes the acronym instead, as in `IDPs`.
+func startsWord(runes []rune, i int) bool {
+	if !unicode.IsUpper(runes[i-1]) {
+		return true
+	}
+	if i+1 >= len(runes) || !unicode.IsLower(runes[i+1]) {
+		return false
+	}
+	plural := runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2]))
+	return !plural
+}
@@ -0,0 +1,169 @@
+package redfox
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestKubeFieldToAttributePath(t *testing.T) {
+	clusterSchema := resourceRedfoxCluster().Schema
+	statusSchema := resourceRedfoxClusterStatus().Schema
+
+	cases := []struct {
+		field string
+		path  cty.Path
+	}{
+		{
+			field: "spec.clusterName",
+			path:  cty.GetAttrPath("spec").IndexInt(0).GetAttr("cluster_name"),
+		},
+		{
+			field: ".spec.infraAccountId",
+			path:  cty.GetAttrPath("spec").IndexInt(0).GetAttr("infra_account_id"),
+		},
+		{
+			field: "spec.databaseSubnetIds[2]",
+			path:  cty.GetAttrPath("spec").IndexInt(0).GetAttr("database_subnet_ids").IndexInt(2),
+		},
+		{
+			field: "metadata.labels[app]",
+			path:  cty.GetAttrPath("metadata").IndexInt(0).GetAttr("labels").IndexString("app"),
+		},
+		{
+			field: "status.apiserver.caCert",
+			path:  cty.GetAttrPath("status").IndexInt(0).GetAttr("apiserver").IndexInt(0).GetAttr("ca_cert"),
+		},
+		{
+			field: "status.awsIamIDPs[dev]",
+			path:  cty.GetAttrPath("status").IndexInt(0).GetAttr("aws_iam_idps").IndexString("dev"),
+		},
+		{
+			field: "spec.unknownField",
+			path:  cty.GetAttrPath("spec"),
+		},
+		{
+			field: "spec.databaseSubnetIds[first]",
+			path:  cty.GetAttrPath("spec").IndexInt(0).GetAttr("database_subnet_ids"),
+		},
+		{
+			field: "spec.clusterName.nested",
+			path:  cty.GetAttrPath("spec").IndexInt(0).GetAttr("cluster_name"),
+		},
+		{
+			field: "unknownField.clusterName",
+		},
+		{
+			field: "",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.field, func(t *testing.T) {
+			resourceSchema := clusterSchema
+			if strings.HasPrefix(tc.field, "status.") {
+				resourceSchema = statusSchema
+			}
+			if path := kubeFieldToAttributePath(tc.field, resourceSchema); !reflect.DeepEqual(path, tc.path) {
+				t.Fatalf("expected %#v, got %#v", tc.path, path)
+			}
+		})
+	}
+}
+
+func TestCamelToSnake(t *testing.T) {
+	cases := map[string]string{
+		"cluster":              "cluster",
+		"clusterName":          "cluster_name",
+		"infraAccountId":       "infra_account_id",
+		"caCert":               "ca_cert",
+		"awsIamIdps":           "aws_iam_idps",
+		"awsIamIDPs":           "aws_iam_idps",
+		"IDPs":                 "idps",
+		"vpcID":                "vpc_id",
+		"apiserverURL":         "apiserver_url",
+		"HTTPServer":           "http_server",
+		"ipv4Cidrs":            "ipv4_cidrs",
+		"serviceAccountIssuer": "service_account_issuer",
+	}
+	for name, expected := range cases {
+		if actual := camelToSnake(name); actual != expected {
+			t.Errorf("camelToSnake(%q): expected %q, got %q", name, expected, actual)
+		}
+	}
+}
+
+func TestKubeErrorDiagnostics(t *testing.T) {
+	clusters := schema.GroupResource{Group: clusterKind.Group, Resource: "clusters"}
+	invalid := errors.NewInvalid(clusterKind.GroupKind(), "dev", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "infraAccountId"), "12", "must be 12 digits"),
+	})
+
+	cases := []struct {
+		name    string
+		err     error
+		summary string
+		detail  string
+		path    cty.Path
+	}{
+		{
+			name:    "invalid",
+			err:     invalid,
+			summary: "Invalid Cluster rejected by the API server: spec.infraAccountId",
+			detail:  "must be 12 digits",
+			path:    cty.GetAttrPath("spec").IndexInt(0).GetAttr("infra_account_id"),
+		},
+		{
+			name:    "wrapped invalid",
+			err:     fmt.Errorf("creating: %w", invalid),
+			summary: "Invalid Cluster rejected by the API server: spec.infraAccountId",
+			detail:  "must be 12 digits",
+			path:    cty.GetAttrPath("spec").IndexInt(0).GetAttr("infra_account_id"),
+		},
+		{
+			name:    "wrapped forbidden",
+			err:     fmt.Errorf("creating: %w", errors.NewForbidden(clusters, "dev", fmt.Errorf("denied"))),
+			summary: "Not allowed to create Cluster",
+			detail:  "Check the RBAC permissions",
+		},
+		{
+			name:    "server timeout",
+			err:     errors.NewServerTimeout(clusters, "create", 5),
+			summary: "Timed out trying to create Cluster",
+			detail:  kubeTimeoutHint,
+		},
+		{
+			name:    "retry timeout",
+			err:     &resource.TimeoutError{LastError: fmt.Errorf("Cluster (dev) still exists"), Timeout: time.Minute},
+			summary: "Timed out trying to create Cluster",
+			detail:  "Cluster (dev) still exists",
+		},
+		{
+			name:    "other error",
+			err:     fmt.Errorf("connection refused"),
+			summary: "Failed to create Cluster",
+			detail:  "connection refused",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := kubeErrorDiagnostics(tc.err, "create", clusterKind.Kind, resourceRedfoxCluster().Schema)
+			if len(diags) != 1 {
+				t.Fatalf("expected one diagnostic, got %v", diags)
+			}
+			if diags[0].Summary != tc.summary || !strings.Contains(diags[0].Detail, tc.detail) {
+				t.Fatalf("expected %q with a detail containing %q, got %q: %q", tc.summary, tc.detail, diags[0].Summary, diags[0].Detail)
+			}
+			if !reflect.DeepEqual(diags[0].AttributePath, tc.path) {
+				t.Fatalf("expected the path %#v, got %#v", tc.path, diags[0].AttributePath)
+			}
+		})
+	}
+}
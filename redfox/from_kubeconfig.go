@@ -0,0 +1,219 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	"github.com/mitchellh/go-homedir"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func fromKubeconfigSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Kubeconfig completing `status.apiserver`: the `endpoint` and `ca_cert` left out of the configuration are read from the cluster of `context` during plan.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"path": {
+					Type:         schema.TypeString,
+					Description:  "Path of the kubeconfig file.",
+					Optional:     true,
+					ExactlyOneOf: []string{"from_kubeconfig.0.path", "from_kubeconfig.0.content"},
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+				"content": {
+					Type:         schema.TypeString,
+					Description:  "Content of the kubeconfig.",
+					Optional:     true,
+					Sensitive:    true,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+				"context": {
+					Type:        schema.TypeString,
+					Description: "Context whose cluster is read. Defaults to the `current-context` of the kubeconfig.",
+					Optional:    true,
+				},
+			},
+		},
+	}
+}
+
+func apiserverComputedSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Endpoint and CA written to `status.apiserver`: its explicit attributes, completed from `from_kubeconfig`.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"endpoint": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"ca_cert": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+// loadKubeconfigApiserver returns the server and certificate authority of the cluster of the
+// context selected by a `from_kubeconfig` block.
+func loadKubeconfigApiserver(in map[string]interface{}) (*redfoxV1alpha1.ApiserverInfo, error) {
+	var config *clientcmdapi.Config
+	if path := in["path"].(string); path != "" {
+		expanded, err := homedir.Expand(path)
+		if err != nil {
+			return nil, err
+		}
+		config, err = clientcmd.LoadFromFile(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("from_kubeconfig.0.path: %s", err)
+		}
+		// Relative paths of a kubeconfig file, such as certificate-authority, are relative to the file
+		if err := clientcmd.ResolveLocalPaths(config); err != nil {
+			return nil, fmt.Errorf("from_kubeconfig.0.path: %s", err)
+		}
+	} else {
+		var err error
+		config, err = clientcmd.Load([]byte(in["content"].(string)))
+		if err != nil {
+			return nil, fmt.Errorf("from_kubeconfig.0.content: %s", err)
+		}
+	}
+
+	name := in["context"].(string)
+	if name == "" {
+		name = config.CurrentContext
+		if name == "" {
+			return nil, fmt.Errorf("the kubeconfig of from_kubeconfig has no current-context, from_kubeconfig.0.context must be set")
+		}
+	}
+	kubeContext, ok := config.Contexts[name]
+	if !ok {
+		names := make([]string, 0, len(config.Contexts))
+		for n := range config.Contexts {
+			names = append(names, fmt.Sprintf("%q", n))
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("context %q is missing from the kubeconfig of from_kubeconfig, available contexts: [%s]", name, strings.Join(names, ", "))
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q of context %q is missing from the kubeconfig of from_kubeconfig", kubeContext.Cluster, name)
+	}
+
+	apiserver := &redfoxV1alpha1.ApiserverInfo{Endpoint: cluster.Server}
+	if len(cluster.CertificateAuthorityData) > 0 {
+		apiserver.CaCert = string(cluster.CertificateAuthorityData)
+	} else if cluster.CertificateAuthority != "" {
+		data, err := os.ReadFile(cluster.CertificateAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("certificate-authority of cluster %q of the kubeconfig of from_kubeconfig: %s", kubeContext.Cluster, err)
+		}
+		apiserver.CaCert = string(data)
+	}
+	return apiserver, nil
+}
+
+// resolveApiserver completes the explicit attributes of apiserver with the `from_kubeconfig` block
+// l. Attributes set in raw, the raw configuration, take precedence.
+func resolveApiserver(raw cty.Value, apiserver redfoxV1alpha1.ApiserverInfo, l []interface{}) (*redfoxV1alpha1.ApiserverInfo, error) {
+	path := cty.GetAttrPath("status").IndexInt(0).GetAttr("apiserver").IndexInt(0)
+	explicit := func(attribute, value string) bool {
+		if raw.IsNull() {
+			return value != ""
+		}
+		return !rawConfigValueIsNull(raw, path.GetAttr(attribute))
+	}
+	explicitEndpoint := explicit("endpoint", apiserver.Endpoint)
+	explicitCaCert := explicit("ca_cert", apiserver.CaCert)
+
+	resolved := &redfoxV1alpha1.ApiserverInfo{}
+	if explicitEndpoint {
+		resolved.Endpoint = apiserver.Endpoint
+	}
+	if explicitCaCert {
+		resolved.CaCert = apiserver.CaCert
+	}
+	if len(l) > 0 && l[0] != nil && !(explicitEndpoint && explicitCaCert) {
+		fromKubeconfig, err := loadKubeconfigApiserver(l[0].(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		if !explicitEndpoint {
+			if _, es := validation.IsURLWithHTTPorHTTPS(fromKubeconfig.Endpoint, "server"); len(es) > 0 {
+				return nil, fmt.Errorf("the kubeconfig of from_kubeconfig has an invalid %s", es[0])
+			}
+			resolved.Endpoint = fromKubeconfig.Endpoint
+		}
+		if !explicitCaCert {
+			if fromKubeconfig.CaCert == "" {
+				return nil, fmt.Errorf("the kubeconfig of from_kubeconfig has no certificate-authority-data, status.0.apiserver.0.ca_cert must be set")
+			}
+			if _, err := decodeCaCert(fromKubeconfig.CaCert); err != nil {
+				return nil, fmt.Errorf("the certificate-authority-data of the kubeconfig of from_kubeconfig %s", err)
+			}
+			resolved.CaCert = fromKubeconfig.CaCert
+		}
+	}
+
+	if resolved.Endpoint == "" {
+		return nil, fmt.Errorf("status.0.apiserver.0.endpoint must be set, or read from `from_kubeconfig`")
+	}
+	if resolved.CaCert == "" {
+		return nil, fmt.Errorf("status.0.apiserver.0.ca_cert must be set, or read from `from_kubeconfig`")
+	}
+	return resolved, nil
+}
+
+// customizeDiffApiserver plans the top-level `apiserver` from `status.apiserver` and `from_kubeconfig`.
+// It stays unknown until its inputs are known, the kubeconfig is then read again during apply.
+func customizeDiffApiserver(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.GetRawConfig().IsNull() {
+		return nil
+	}
+	// Omitted attributes of status.apiserver are unknown until read, only configured ones are checked
+	raw := d.GetRawConfig()
+	apiserverPath := cty.GetAttrPath("status").IndexInt(0).GetAttr("apiserver").IndexInt(0)
+	fromKubeconfigPath := cty.GetAttrPath("from_kubeconfig").IndexInt(0)
+	for _, path := range []cty.Path{
+		apiserverPath.GetAttr("endpoint"),
+		apiserverPath.GetAttr("ca_cert"),
+		fromKubeconfigPath.GetAttr("path"),
+		fromKubeconfigPath.GetAttr("content"),
+		fromKubeconfigPath.GetAttr("context"),
+	} {
+		if v, err := path.Apply(raw); err == nil && !v.IsKnown() {
+			return d.SetNewComputed("apiserver")
+		}
+	}
+
+	apiserver := redfoxV1alpha1.ApiserverInfo{
+		Endpoint: d.Get("status.0.apiserver.0.endpoint").(string),
+		CaCert:   d.Get("status.0.apiserver.0.ca_cert").(string),
+	}
+	resolved, err := resolveApiserver(raw, apiserver, d.Get("from_kubeconfig").([]interface{}))
+	if err != nil {
+		return err
+	}
+	return d.SetNew("apiserver", flattenApiserver(*resolved))
+}
+
+func flattenApiserver(in redfoxV1alpha1.ApiserverInfo) []any {
+	return []any{map[string]any{
+		"endpoint": in.Endpoint,
+		"ca_cert":  in.CaCert,
+	}}
+}
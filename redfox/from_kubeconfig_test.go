@@ -0,0 +1,72 @@
+package redfox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+    certificate-authority: %s
+contexts:
+- name: dev
+  context:
+    cluster: dev
+`
+
+func TestLoadKubeconfigApiserver(t *testing.T) {
+	_, _, caPem := testCaCert(t, "kubernetes")
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pki"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pki", "ca.crt"), []byte(caPem), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                 string
+		certificateAuthority string
+		error                string
+	}{
+		{
+			name:                 "relative to the kubeconfig",
+			certificateAuthority: "pki/ca.crt",
+		},
+		{
+			name:                 "absolute",
+			certificateAuthority: filepath.Join(dir, "pki", "ca.crt"),
+		},
+		{
+			name:                 "missing",
+			certificateAuthority: "pki/missing.crt",
+			error:                `certificate-authority of cluster "dev" of the kubeconfig of from_kubeconfig`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config")
+			if err := os.WriteFile(path, []byte(strings.Replace(testKubeconfig, "%s", tc.certificateAuthority, 1)), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			apiserver, err := loadKubeconfigApiserver(map[string]interface{}{"path": path, "content": "", "context": ""})
+			switch {
+			case tc.error != "":
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case apiserver.Endpoint != "https://dev.example.com" || apiserver.CaCert != caPem:
+				t.Fatalf("unexpected apiserver %#v", apiserver)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	identityChangeError   = "error"
+	identityChangeReplace = "replace"
+)
+
+// clusterIdentityKeys are the attributes of a Cluster which identify the cluster it describes.
+// Rewriting them in place would make the object describe another cluster. The top-level
+// `cluster_name` stands for `spec.cluster_name`, as it also changes when the name is computed.
+var clusterIdentityKeys = []string{
+	"spec.0.infra_vendor",
+	"spec.0.infra_account_id",
+	"spec.0.cluster_engine",
+	"spec.0.cluster_region",
+	"cluster_name",
+}
+
+func identityChangeSchema(objectName string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Description:  fmt.Sprintf("What to do when an identity attribute of the %s (`%s`) changes. `error` fails the plan, `replace` plans to destroy and create the %s again.", objectName, strings.Join(clusterIdentityKeys, "`, `"), objectName),
+		Optional:     true,
+		Default:      identityChangeError,
+		ValidateFunc: validation.StringInSlice([]string{identityChangeError, identityChangeReplace}, false),
+	}
+}
+
+// customizeDiffIdentityChange enforces `identity_change` for keys. Keys whose new value is not
+// known yet are checked again once it is, keys without an old value are not checked.
+func customizeDiffIdentityChange(keys []string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if d.Id() == "" {
+			return nil
+		}
+
+		var changed, changes []string
+		for _, key := range keys {
+			if !d.HasChange(key) || !d.NewValueKnown(key) {
+				continue
+			}
+			o, n := d.GetChange(key)
+			// An empty old value was never recorded, as for `cluster_name` in states written before
+			// it existed and not refreshed since, and is not an identity change.
+			if o == "" {
+				continue
+			}
+			changed = append(changed, key)
+			changes = append(changes, fmt.Sprintf("%s: %q => %q", key, o, n))
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+
+		if d.Get("identity_change").(string) == identityChangeReplace {
+			for _, key := range changed {
+				if err := d.ForceNew(key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return fmt.Errorf("identity attributes of %s cannot be changed in place:\n  - %s\nSet `identity_change = %q` to replace the object instead.", d.Id(), strings.Join(changes, "\n  - "), identityChangeReplace)
+	}
+}
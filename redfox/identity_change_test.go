@@ -0,0 +1,92 @@
+package redfox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// testClusterState returns the state of a Cluster applied from config, given as it would be in JSON,
+// with the attributes of drop removed.
+func testClusterState(t *testing.T, config map[string]interface{}, drop ...string) *terraform.InstanceState {
+	t.Helper()
+	d := schema.TestResourceDataRaw(t, resourceRedfoxCluster().Schema, config)
+	d.SetId(defaultNamespace + "/test")
+	if err := d.Set("cluster_name", "test"); err != nil {
+		t.Fatal(err)
+	}
+	state := d.State()
+	for _, key := range drop {
+		delete(state.Attributes, key)
+	}
+	return state
+}
+
+func TestCustomizeDiffIdentityChange(t *testing.T) {
+	cases := []struct {
+		name     string
+		drop     []string
+		spec     map[string]interface{}
+		change   string
+		forceNew []string
+		error    string
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name:  "changed region",
+			spec:  map[string]interface{}{"cluster_region": "us-east-1"},
+			error: `spec.0.cluster_region: "ap-northeast-2" => "us-east-1"`,
+		},
+		{
+			name:  "changed cluster name",
+			spec:  map[string]interface{}{"cluster_name": "renamed"},
+			error: `cluster_name: "test" => "renamed"`,
+		},
+		{
+			name:     "changed cluster name, replace",
+			spec:     map[string]interface{}{"cluster_name": "renamed"},
+			change:   identityChangeReplace,
+			forceNew: []string{"cluster_name"},
+		},
+		{
+			name: "cluster name missing from the state",
+			drop: []string{"cluster_name"},
+		},
+		{
+			name:  "cluster name missing from the state, changed region",
+			drop:  []string{"cluster_name"},
+			spec:  map[string]interface{}{"cluster_region": "us-east-1"},
+			error: `spec.0.cluster_region: "ap-northeast-2" => "us-east-1"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := testClusterState(t, testClusterConfig(nil), tc.drop...)
+			config := testClusterConfig(tc.spec)
+			if tc.change != "" {
+				config["identity_change"] = tc.change
+			}
+			customizeDiff := customdiff.All(customizeDiffClusterName, customizeDiffIdentityChange(clusterIdentityKeys))
+			diff, err := testResourceDiff(t, resourceRedfoxCluster(), customizeDiff, state, config, kubeClientsets{})
+			if tc.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for _, key := range tc.forceNew {
+				if attr := diff.Attributes[key]; attr == nil || !attr.RequiresNew {
+					t.Errorf("expected %s to require a new object, got %#v", key, attr)
+				}
+			}
+		})
+	}
+}
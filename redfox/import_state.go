@@ -0,0 +1,166 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// importId is the parsed form of an identifier given to `terraform import`.
+type importId struct {
+	namespace   string
+	name        string
+	uid         string
+	apiVersion  string
+	kind        string
+	clusterName string
+}
+
+// parseImportId accepts `name`, `namespace/name`, `uid:<uid>`, `cluster_name=<value>[,namespace=<ns>]`
+// and the `apiVersion=...,kind=...,name=...,namespace=...` form produced by buildIdWithVersionKind.
+// Segments are trimmed of surrounding spaces and must not be empty. The namespace is left empty
+// when a `key=value` ID does not set it.
+func parseImportId(id string) (*importId, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("import ID must not be empty")
+	}
+
+	if strings.HasPrefix(id, "uid:") {
+		uid := strings.TrimSpace(strings.TrimPrefix(id, "uid:"))
+		if uid == "" {
+			return nil, fmt.Errorf("Unexpected import ID format (%q), expected %q.", id, "uid:<uid>")
+		}
+		return &importId{uid: uid}, nil
+	}
+
+	if strings.Contains(id, "=") {
+		out := &importId{}
+		for _, pair := range strings.Split(id, ",") {
+			key, value, found := strings.Cut(pair, "=")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if !found || key == "" || value == "" {
+				return nil, fmt.Errorf("Unexpected import ID format (%q), expected comma separated %q pairs with non-empty keys and values.", id, "key=value")
+			}
+			switch key {
+			case "apiVersion":
+				out.apiVersion = value
+			case "kind":
+				out.kind = value
+			case "name":
+				out.name = value
+			case "namespace":
+				out.namespace = value
+			case "cluster_name":
+				out.clusterName = value
+			default:
+				return nil, fmt.Errorf("Unexpected key %q in import ID (%q), expected one of apiVersion, kind, name, namespace, cluster_name.", key, id)
+			}
+		}
+		if out.name == "" && out.clusterName == "" {
+			return nil, fmt.Errorf("Import ID (%q) is missing the %q or %q key.", id, "name", "cluster_name")
+		}
+		if out.name != "" && out.clusterName != "" {
+			return nil, fmt.Errorf("Import ID (%q) must not set both %q and %q.", id, "name", "cluster_name")
+		}
+		if out.name != "" && out.namespace == "" {
+			out.namespace = defaultNamespace
+		}
+		return out, nil
+	}
+
+	if strings.Contains(id, "/") {
+		namespace, name, err := idParts(id)
+		if err != nil {
+			return nil, err
+		}
+		namespace, name = strings.TrimSpace(namespace), strings.TrimSpace(name)
+		if namespace == "" || name == "" {
+			return nil, fmt.Errorf("Unexpected import ID format (%q), expected %q with a non-empty namespace and name.", id, "namespace/name")
+		}
+		return &importId{namespace: namespace, name: name}, nil
+	}
+
+	return &importId{namespace: defaultNamespace, name: id}, nil
+}
+
+// importLookup resolves import IDs of a single kind against the API server.
+type importLookup struct {
+	kind kubeSchema.GroupVersionKind
+	// get returns the metadata of the named object, failing when it cannot be imported.
+	get func(ctx context.Context, meta interface{}, namespace, name string) (*metav1.ObjectMeta, error)
+	// list returns the metadata of every object in namespace, or in all namespaces if empty.
+	list func(ctx context.Context, meta interface{}, namespace string) ([]metav1.ObjectMeta, error)
+	// listByClusterName returns the metadata of every object whose spec.cluster_name matches,
+	// in namespace or in all namespaces if empty. It is nil for kinds without a cluster name.
+	listByClusterName func(ctx context.Context, meta interface{}, namespace, clusterName string) ([]metav1.ObjectMeta, error)
+}
+
+func (l importLookup) StateContext(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	id, err := parseImportId(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if id.apiVersion != "" && id.apiVersion != l.kind.GroupVersion().String() {
+		return nil, fmt.Errorf("Import ID (%q) refers to apiVersion %q, expected %q.", d.Id(), id.apiVersion, l.kind.GroupVersion().String())
+	}
+	if id.kind != "" && id.kind != l.kind.Kind {
+		return nil, fmt.Errorf("Import ID (%q) refers to kind %q, expected %q.", d.Id(), id.kind, l.kind.Kind)
+	}
+
+	if id.uid != "" {
+		items, err := l.list(ctx, meta, "")
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list %s to resolve UID %q: %s", l.kind.Kind, id.uid, err)
+		}
+		found := false
+		for _, item := range items {
+			if string(item.UID) == id.uid {
+				id.namespace, id.name, found = item.Namespace, item.Name, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Cannot import: no %s with UID %q exists.", l.kind.Kind, id.uid)
+		}
+	}
+
+	if id.clusterName != "" {
+		if l.listByClusterName == nil {
+			return nil, fmt.Errorf("Import by %q is not supported for %s.", "cluster_name", l.kind.Kind)
+		}
+		items, err := l.listByClusterName(ctx, meta, id.namespace, id.clusterName)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list %s to resolve cluster_name %q: %s", l.kind.Kind, id.clusterName, err)
+		}
+		if len(items) != 1 {
+			candidates := lo.Map[metav1.ObjectMeta, string](items, func(x metav1.ObjectMeta, _ int) string {
+				return buildId(x)
+			})
+			sort.Strings(candidates)
+			return nil, fmt.Errorf("Cannot import: expected exactly one %s with cluster_name %q, found %d: [%s]", l.kind.Kind, id.clusterName, len(items), strings.Join(candidates, ", "))
+		}
+		id.namespace, id.name = items[0].Namespace, items[0].Name
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("Importing %s %s/%s", l.kind.Kind, id.namespace, id.name))
+	om, err := l.get(ctx, meta, id.namespace, id.name)
+	if err != nil {
+		if statusErr, ok := err.(*errors.StatusError); ok && errors.IsNotFound(statusErr) {
+			return nil, fmt.Errorf("Cannot import: %s %q does not exist.", l.kind.Kind, id.namespace+"/"+id.name)
+		}
+		return nil, err
+	}
+
+	d.SetId(buildId(*om))
+	return []*schema.ResourceData{d}, nil
+}
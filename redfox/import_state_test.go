@@ -0,0 +1,117 @@
+package redfox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseImportId(t *testing.T) {
+	cases := []struct {
+		id       string
+		expected *importId
+		error    string
+	}{
+		{
+			id:       "dev",
+			expected: &importId{namespace: defaultNamespace, name: "dev"},
+		},
+		{
+			id:       " dev ",
+			expected: &importId{namespace: defaultNamespace, name: "dev"},
+		},
+		{
+			id:       "infra/dev",
+			expected: &importId{namespace: "infra", name: "dev"},
+		},
+		{
+			id:       "infra / dev",
+			expected: &importId{namespace: "infra", name: "dev"},
+		},
+		{
+			id:       "uid:0b4e7f5c-8a4e-4d4e-9c3e-2f6a1b7d9e01",
+			expected: &importId{uid: "0b4e7f5c-8a4e-4d4e-9c3e-2f6a1b7d9e01"},
+		},
+		{
+			id:       "cluster_name=dev-apne2",
+			expected: &importId{clusterName: "dev-apne2"},
+		},
+		{
+			id:       "cluster_name=dev-apne2,namespace=infra",
+			expected: &importId{namespace: "infra", clusterName: "dev-apne2"},
+		},
+		{
+			id:       "apiVersion=metadata.sbx-central.io/v1alpha1,kind=Cluster,name=dev,namespace=infra",
+			expected: &importId{apiVersion: "metadata.sbx-central.io/v1alpha1", kind: "Cluster", namespace: "infra", name: "dev"},
+		},
+		{
+			id:       "apiVersion=metadata.sbx-central.io/v1alpha1, kind=Cluster, name=dev",
+			expected: &importId{apiVersion: "metadata.sbx-central.io/v1alpha1", kind: "Cluster", namespace: defaultNamespace, name: "dev"},
+		},
+		{
+			id:    "",
+			error: "import ID must not be empty",
+		},
+		{
+			id:    "infra/",
+			error: "non-empty namespace and name",
+		},
+		{
+			id:    "/dev",
+			error: "non-empty namespace and name",
+		},
+		{
+			id:    "infra/ ",
+			error: "non-empty namespace and name",
+		},
+		{
+			id:    "a/b/c",
+			error: `expected "namespace/name"`,
+		},
+		{
+			id:    "uid: ",
+			error: `expected "uid:<uid>"`,
+		},
+		{
+			id:    "name=dev,,namespace=infra",
+			error: "non-empty keys and values",
+		},
+		{
+			id:    "name= ,namespace=infra",
+			error: "non-empty keys and values",
+		},
+		{
+			id:    "=dev",
+			error: "non-empty keys and values",
+		},
+		{
+			id:    "name=dev,color=red",
+			error: `Unexpected key "color"`,
+		},
+		{
+			id:    "kind=Cluster,namespace=infra",
+			error: `missing the "name" or "cluster_name" key`,
+		},
+		{
+			id:    "name=dev,cluster_name=dev-apne2",
+			error: `must not set both "name" and "cluster_name"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.id, func(t *testing.T) {
+			id, err := parseImportId(tc.id)
+			if tc.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(id, tc.expected) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, id)
+			}
+		})
+	}
+}
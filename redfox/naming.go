@@ -0,0 +1,140 @@
+package redfox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// namingTemplateFuncs are the functions available to the naming templates of the provider.
+var namingTemplateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"truncate": func(n int, s string) string {
+		if len(s) > n {
+			return s[:n]
+		}
+		return s
+	},
+}
+
+func parseNamingTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(namingTemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// renderNamingTemplate renders text, a Go template using namingTemplateFuncs, against data.
+func renderNamingTemplate(name, text string, data interface{}) (string, error) {
+	t, err := parseNamingTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func validateNamingTemplate(value interface{}, key string) (ws []string, es []error) {
+	if _, err := parseNamingTemplate(key, value.(string)); err != nil {
+		es = append(es, fmt.Errorf("%s: invalid template: %s", key, err))
+	}
+	return
+}
+
+// clusterNamingData is the data given to the `naming.cluster_name` template of the provider.
+type clusterNamingData struct {
+	Namespace      string
+	ClusterGroup   string
+	ClusterEngine  string
+	ClusterRegion  string
+	InfraVendor    string
+	InfraAccountId string
+	ServicePhase   string
+	ServiceTag     string
+}
+
+// clusterNamingInputs maps the spec attributes available to the naming template to their field in clusterNamingData.
+var clusterNamingInputs = map[string]func(*clusterNamingData, string){
+	"cluster_group":    func(x *clusterNamingData, v string) { x.ClusterGroup = v },
+	"cluster_engine":   func(x *clusterNamingData, v string) { x.ClusterEngine = v },
+	"cluster_region":   func(x *clusterNamingData, v string) { x.ClusterRegion = v },
+	"infra_vendor":     func(x *clusterNamingData, v string) { x.InfraVendor = v },
+	"infra_account_id": func(x *clusterNamingData, v string) { x.InfraAccountId = v },
+	"service_phase":    func(x *clusterNamingData, v string) { x.ServicePhase = v },
+	"service_tag":      func(x *clusterNamingData, v string) { x.ServiceTag = v },
+}
+
+// customizeDiffClusterName plans the top-level `cluster_name`: the explicit `spec.cluster_name`, or
+// the rendering of the provider `naming.cluster_name` template when it is omitted. Explicit names
+// must match the template when one is configured. The name stays unknown until its inputs are known.
+func customizeDiffClusterName(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	var nameTemplate string
+	if m, ok := meta.(kubeClientsets); ok {
+		nameTemplate = m.ClusterNameTemplate
+	}
+
+	if d.GetRawConfig().IsNull() {
+		return nil
+	}
+	explicit := !rawConfigValueIsNull(d.GetRawConfig(), cty.GetAttrPath("spec").IndexInt(0).GetAttr("cluster_name"))
+	if explicit && !d.NewValueKnown("spec.0.cluster_name") {
+		return d.SetNewComputed("cluster_name")
+	}
+	if nameTemplate == "" {
+		if !explicit {
+			return fmt.Errorf("spec.0.cluster_name must be set, or the provider must configure a `naming` template to compute it")
+		}
+		return d.SetNew("cluster_name", d.Get("spec.0.cluster_name").(string))
+	}
+
+	data := &clusterNamingData{}
+	if !d.NewValueKnown("metadata.0.namespace") {
+		return d.SetNewComputed("cluster_name")
+	}
+	data.Namespace = d.Get("metadata.0.namespace").(string)
+	for attribute, set := range clusterNamingInputs {
+		key := "spec.0." + attribute
+		if !d.NewValueKnown(key) {
+			if explicit {
+				return d.SetNew("cluster_name", d.Get("spec.0.cluster_name").(string))
+			}
+			return d.SetNewComputed("cluster_name")
+		}
+		set(data, d.Get(key).(string))
+	}
+
+	rendered, err := renderNamingTemplate("cluster_name", nameTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render the `naming.cluster_name` template of the provider: %s", err)
+	}
+	if rendered == "" {
+		return fmt.Errorf("the `naming.cluster_name` template of the provider rendered an empty cluster name")
+	}
+
+	if explicit {
+		name := d.Get("spec.0.cluster_name").(string)
+		if name != rendered {
+			return fmt.Errorf("spec.0.cluster_name %q does not follow the naming convention of the provider, expected %q", name, rendered)
+		}
+	}
+	return d.SetNew("cluster_name", rendered)
+}
+
+// rawConfigValueIsNull reports whether the value at path of a raw configuration is null or missing.
+func rawConfigValueIsNull(raw cty.Value, path cty.Path) bool {
+	v, err := path.Apply(raw)
+	if err != nil {
+		return true
+	}
+	return v.IsNull()
+}
@@ -0,0 +1,174 @@
+package redfox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderNamingTemplate(t *testing.T) {
+	data := &clusterNamingData{
+		Namespace:     "infra",
+		ClusterGroup:  "game",
+		ClusterRegion: "ap-northeast-2",
+		InfraVendor:   "AWS",
+		ServicePhase:  "dev",
+		ServiceTag:    " blue ",
+	}
+	cases := []struct {
+		template string
+		expected string
+		error    string
+	}{
+		{
+			template: "{{.ServicePhase}}-{{.ClusterGroup}}-{{.InfraVendor | lower}}",
+			expected: "dev-game-aws",
+		},
+		{
+			template: "{{.ClusterRegion | replace \"-\" \"\" | upper}}",
+			expected: "APNORTHEAST2",
+		},
+		{
+			template: "{{.ServiceTag | trim}}-{{.ClusterRegion | trimPrefix \"ap-\" | trimSuffix \"-2\"}}",
+			expected: "blue-northeast",
+		},
+		{
+			template: "{{.ClusterGroup | truncate 2}}{{.Namespace | truncate 10}}",
+			expected: "gainfra",
+		},
+		{
+			template: "{{.Missing}}",
+			error:    "can't evaluate field Missing",
+		},
+		{
+			template: "{{.ServicePhase",
+			error:    "unclosed action",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.template, func(t *testing.T) {
+			rendered, err := renderNamingTemplate("cluster_name", tc.template, data)
+			switch {
+			case tc.error != "":
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case rendered != tc.expected:
+				t.Fatalf("expected %q, got %q", tc.expected, rendered)
+			}
+		})
+	}
+}
+
+func TestValidateNamingTemplate(t *testing.T) {
+	cases := []struct {
+		template string
+		error    string
+	}{
+		{
+			template: "{{.ServicePhase}}-{{.InfraVendor | lower}}",
+		},
+		{
+			template: "static",
+		},
+		{
+			template: "{{.ServicePhase",
+			error:    "naming.0.cluster_name: invalid template:",
+		},
+		{
+			template: "{{.ServicePhase | unknown}}",
+			error:    `function "unknown" not defined`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, es := validateNamingTemplate(tc.template, "naming.0.cluster_name")
+			if tc.error == "" {
+				if len(es) > 0 {
+					t.Fatalf("unexpected errors: %v", es)
+				}
+				return
+			}
+			if len(es) != 1 || !strings.Contains(es[0].Error(), tc.error) {
+				t.Fatalf("expected an error containing %q, got %v", tc.error, es)
+			}
+		})
+	}
+}
+
+func TestCustomizeDiffClusterName(t *testing.T) {
+	const nameTemplate = "{{.ServicePhase}}-{{.ServiceTag}}-{{.InfraVendor | lower}}"
+	cases := []struct {
+		name         string
+		template     string
+		spec         map[string]interface{}
+		clusterName  string
+		computedName bool
+		error        string
+	}{
+		{
+			name:        "explicit without template",
+			clusterName: "test",
+		},
+		{
+			name:  "omitted without template",
+			spec:  map[string]interface{}{"cluster_name": nil},
+			error: "spec.0.cluster_name must be set",
+		},
+		{
+			name:        "rendered",
+			template:    nameTemplate,
+			spec:        map[string]interface{}{"cluster_name": nil},
+			clusterName: "dev-test-aws",
+		},
+		{
+			name:        "explicit following the template",
+			template:    nameTemplate,
+			spec:        map[string]interface{}{"cluster_name": "dev-test-aws"},
+			clusterName: "dev-test-aws",
+		},
+		{
+			name:     "explicit not following the template",
+			template: nameTemplate,
+			error:    `spec.0.cluster_name "test" does not follow the naming convention of the provider, expected "dev-test-aws"`,
+		},
+		{
+			name:         "unknown input",
+			template:     nameTemplate,
+			spec:         map[string]interface{}{"cluster_name": nil, "service_tag": testUnknown},
+			computedName: true,
+		},
+		{
+			name:         "unknown explicit name",
+			template:     nameTemplate,
+			spec:         map[string]interface{}{"cluster_name": testUnknown},
+			computedName: true,
+		},
+		{
+			name:     "empty rendering",
+			template: "{{.Namespace | truncate 0}}",
+			spec:     map[string]interface{}{"cluster_name": nil},
+			error:    "rendered an empty cluster name",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := kubeClientsets{ClusterNameTemplate: tc.template}
+			diff, err := testResourceDiff(t, resourceRedfoxCluster(), customizeDiffClusterName, nil, testClusterConfig(tc.spec), meta)
+			if tc.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			attr := diff.Attributes["cluster_name"]
+			if attr == nil || attr.NewComputed != tc.computedName || (!tc.computedName && attr.New != tc.clusterName) {
+				t.Fatalf("expected cluster_name %q (computed: %t), got %#v", tc.clusterName, tc.computedName, attr)
+			}
+		})
+	}
+}
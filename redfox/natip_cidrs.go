@@ -0,0 +1,151 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// parseCidr returns the network of cidr, with host bits cleared. IPv4-mapped IPv6 networks such as
+// `::ffff:10.0.0.0/120` stay IPv6 networks, rather than being unmapped as net.ParseCIDR does.
+func parseCidr(cidr string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR address: %s", cidr)
+	}
+	return prefix.Masked(), nil
+}
+
+// canonicalCidr returns the network of cidr in its canonical notation: host bits cleared and IPv6
+// addresses in lower-case compressed form.
+func canonicalCidr(cidr string) (string, error) {
+	network, err := parseCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	return network.String(), nil
+}
+
+// hashCidr hashes CIDRs by their canonical notation, so that equivalent notations are one set element.
+func hashCidr(v interface{}) int {
+	if canonical, err := canonicalCidr(v.(string)); err == nil {
+		return schema.HashString(canonical)
+	}
+	return schema.HashString(v)
+}
+
+func validateCidr(value interface{}, key string) (ws []string, es []error) {
+	v := value.(string)
+	canonical, err := canonicalCidr(v)
+	if err != nil {
+		es = append(es, fmt.Errorf("expected %s to contain a valid CIDR, got %q: %s", key, v, err))
+		return
+	}
+	if canonical != v {
+		ws = append(ws, fmt.Sprintf("%s %q is not in canonical notation and is sent as %q", key, v, canonical))
+	}
+	return
+}
+
+// suppressEquivalentCidr ignores changes between notations of the same network.
+func suppressEquivalentCidr(k, old, new string, d *schema.ResourceData) bool {
+	oldCanonical, err := canonicalCidr(old)
+	if err != nil {
+		return false
+	}
+	newCanonical, err := canonicalCidr(new)
+	return err == nil && oldCanonical == newCanonical
+}
+
+// natIpCidrsSchema returns the schema of `spec.cidrs`. CIDRs are a set of networks, so their order
+// does not matter and notations of the same network collapse into one.
+func natIpCidrsSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Classless Inter-Domain Routing notated networks, such as `10.0.0.0/24` or `2001:db8::/32`. They are sent in canonical notation, with host bits cleared and IPv6 addresses in lower-case compressed form. IPv4-mapped IPv6 networks such as `::ffff:10.0.0.0/120` stay IPv6 networks.",
+		Type:        schema.TypeSet,
+		Required:    true,
+		Set:         hashCidr,
+		Elem: &schema.Schema{
+			Type:             schema.TypeString,
+			ValidateFunc:     validateCidr,
+			DiffSuppressFunc: suppressEquivalentCidr,
+		},
+	}
+}
+
+// canonicalCidrs canonicalizes cidrs, removes duplicated networks and sorts them with IPv4 networks
+// first, then by address and prefix length. Invalid CIDRs are kept as they are, after valid ones.
+func canonicalCidrs(cidrs []string) []string {
+	type network struct {
+		cidr   string
+		prefix netip.Prefix
+		valid  bool
+	}
+	seen := map[string]bool{}
+	var networks []network
+	for _, cidr := range cidrs {
+		n := network{cidr: cidr}
+		if prefix, err := parseCidr(cidr); err == nil {
+			n.cidr = prefix.String()
+			n.prefix = prefix
+			n.valid = true
+		}
+		if seen[n.cidr] {
+			continue
+		}
+		seen[n.cidr] = true
+		networks = append(networks, n)
+	}
+	sort.SliceStable(networks, func(i, j int) bool {
+		a, b := networks[i], networks[j]
+		if a.valid != b.valid {
+			return a.valid
+		}
+		if !a.valid {
+			return a.cidr < b.cidr
+		}
+		if a.prefix.Addr().Is4() != b.prefix.Addr().Is4() {
+			return a.prefix.Addr().Is4()
+		}
+		if c := a.prefix.Addr().Compare(b.prefix.Addr()); c != 0 {
+			return c < 0
+		}
+		return a.prefix.Bits() < b.prefix.Bits()
+	})
+	out := make([]string, 0, len(networks))
+	for _, n := range networks {
+		out = append(out, n.cidr)
+	}
+	return out
+}
+
+// customizeDiffNatIpCidrs rejects `spec.cidrs` listing the same network more than once, such as
+// `10.0.0.0/24` and `10.0.0.5/24`. The set hides them, so the raw configuration is checked.
+func customizeDiffNatIpCidrs(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	raw, err := cty.GetAttrPath("spec").IndexInt(0).GetAttr("cidrs").Apply(d.GetRawConfig())
+	if err != nil || raw.IsNull() || !raw.IsKnown() || !raw.CanIterateElements() {
+		return nil
+	}
+
+	networks := map[string]string{}
+	for it := raw.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		if !v.IsKnown() || v.IsNull() {
+			continue
+		}
+		cidr := v.AsString()
+		canonical, err := canonicalCidr(cidr)
+		if err != nil {
+			continue
+		}
+		if other, ok := networks[canonical]; ok {
+			return fmt.Errorf("spec.0.cidrs lists network %s more than once: %q and %q", canonical, other, cidr)
+		}
+		networks[canonical] = cidr
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package redfox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+)
+
+func TestCanonicalCidr(t *testing.T) {
+	cases := []struct {
+		cidr      string
+		canonical string
+		ipType    redfoxV1alpha1.IpType
+	}{
+		{cidr: "10.0.0.0/24", canonical: "10.0.0.0/24", ipType: redfoxV1alpha1.Ipv4},
+		{cidr: "10.0.0.5/24", canonical: "10.0.0.0/24", ipType: redfoxV1alpha1.Ipv4},
+		{cidr: "0.0.0.0/0", canonical: "0.0.0.0/0", ipType: redfoxV1alpha1.Ipv4},
+		{cidr: "2001:DB8:0:0::1/32", canonical: "2001:db8::/32", ipType: redfoxV1alpha1.Ipv6},
+		{cidr: "::/0", canonical: "::/0", ipType: redfoxV1alpha1.Ipv6},
+		{cidr: "::ffff:10.0.0.0/120", canonical: "::ffff:10.0.0.0/120", ipType: redfoxV1alpha1.Ipv6},
+		{cidr: "::ffff:10.0.0.5/120", canonical: "::ffff:10.0.0.0/120", ipType: redfoxV1alpha1.Ipv6},
+		{cidr: "::FFFF:0:0/96", canonical: "::ffff:0.0.0.0/96", ipType: redfoxV1alpha1.Ipv6},
+	}
+	for _, tc := range cases {
+		t.Run(tc.cidr, func(t *testing.T) {
+			canonical, err := canonicalCidr(tc.cidr)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if canonical != tc.canonical {
+				t.Errorf("canonicalCidr(%q) = %q, want %q", tc.cidr, canonical, tc.canonical)
+			}
+			for _, cidr := range []string{tc.cidr, canonical} {
+				if ipType, err := natIpCidrType(cidr); err != nil || ipType != tc.ipType {
+					t.Errorf("natIpCidrType(%q) = %q, %v, want %q", cidr, ipType, err, tc.ipType)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCidr(t *testing.T) {
+	cases := []struct {
+		cidr     string
+		warnings int
+		errors   int
+	}{
+		{cidr: "10.0.0.0/24"},
+		{cidr: "::ffff:10.0.0.0/120"},
+		{cidr: "10.0.0.5/24", warnings: 1},
+		{cidr: "::ffff:0:0/96", warnings: 1},
+		{cidr: "10.0.0.0", errors: 1},
+		{cidr: "10.0.0.0/33", errors: 1},
+		{cidr: "fe80::1%eth0/64", errors: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.cidr, func(t *testing.T) {
+			ws, es := validateCidr(tc.cidr, "spec.0.cidrs")
+			if len(ws) != tc.warnings || len(es) != tc.errors {
+				t.Fatalf("validateCidr(%q) = %v, %v, want %d warnings and %d errors", tc.cidr, ws, es, tc.warnings, tc.errors)
+			}
+		})
+	}
+}
+
+func TestCanonicalCidrs(t *testing.T) {
+	got := canonicalCidrs([]string{
+		"invalid",
+		"2001:db8::/32",
+		"::ffff:10.0.0.0/120",
+		"10.0.0.5/24",
+		"10.0.0.0/16",
+		"10.0.0.0/24",
+		"192.168.0.0/16",
+		"::/0",
+	})
+	want := []string{
+		"10.0.0.0/16",
+		"10.0.0.0/24",
+		"192.168.0.0/16",
+		"::/0",
+		"::ffff:10.0.0.0/120",
+		"2001:db8::/32",
+		"invalid",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("canonicalCidrs() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveNatIpType(t *testing.T) {
+	cases := []struct {
+		name     string
+		declared redfoxV1alpha1.IpType
+		cidrs    []string
+		want     redfoxV1alpha1.IpType
+		error    bool
+	}{
+		{name: "inferred IPv4", cidrs: []string{"10.0.0.0/24"}, want: redfoxV1alpha1.Ipv4},
+		{name: "inferred IPv6", cidrs: []string{"::ffff:10.0.0.0/120"}, want: redfoxV1alpha1.Ipv6},
+		{name: "mixed", cidrs: []string{"10.0.0.0/24", "::ffff:10.0.0.0/120"}, error: true},
+		{name: "declared IPv4 with IPv4-mapped networks", declared: redfoxV1alpha1.Ipv4, cidrs: []string{"::ffff:10.0.0.0/120"}, error: true},
+		{name: "declared IPv6", declared: redfoxV1alpha1.Ipv6, cidrs: []string{"::ffff:10.0.0.0/120", "2001:db8::/32"}, want: redfoxV1alpha1.Ipv6},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolveNatIpType(tc.declared, tc.cidrs)
+			if (err != nil) != tc.error || got != tc.want {
+				t.Fatalf("resolveNatIpType() = %q, %v, want %q and error %v", got, err, tc.want, tc.error)
+			}
+		})
+	}
+}
+
+func TestCustomizeDiffNatIpType(t *testing.T) {
+	cases := []struct {
+		name   string
+		spec   map[string]interface{}
+		ipType string
+		error  string
+	}{
+		{
+			name:   "inferred IPv4",
+			spec:   map[string]interface{}{"cidrs": []interface{}{"10.0.0.5/24"}},
+			ipType: string(redfoxV1alpha1.Ipv4),
+		},
+		{
+			name:   "inferred IPv6 from IPv4-mapped networks",
+			spec:   map[string]interface{}{"cidrs": []interface{}{"::ffff:10.0.0.5/120"}},
+			ipType: string(redfoxV1alpha1.Ipv6),
+		},
+		{
+			name:   "declared",
+			spec:   map[string]interface{}{"ip_type": string(redfoxV1alpha1.Ipv6), "cidrs": []interface{}{"2001:db8::/32", "::ffff:10.0.0.0/120"}},
+			ipType: string(redfoxV1alpha1.Ipv6),
+		},
+		{
+			name:  "declared family mismatch",
+			spec:  map[string]interface{}{"ip_type": string(redfoxV1alpha1.Ipv4), "cidrs": []interface{}{"10.0.0.0/24", "::ffff:10.0.0.5/120"}},
+			error: "spec.0.cidrs must all be Ipv4 networks as declared by spec.0.ip_type, got ::ffff:10.0.0.0/120",
+		},
+		{
+			name:  "mixed families",
+			spec:  map[string]interface{}{"cidrs": []interface{}{"10.0.0.0/24", "::ffff:10.0.0.0/120"}},
+			error: "spec.0.cidrs mixes Ipv4 networks (10.0.0.0/24) and Ipv6 networks (::ffff:10.0.0.0/120)",
+		},
+		{
+			name: "unknown CIDR",
+			spec: map[string]interface{}{"cidrs": []interface{}{"10.0.0.0/24", testUnknown}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := map[string]interface{}{
+				"metadata": []interface{}{map[string]interface{}{"name": "test"}},
+				"spec":     []interface{}{tc.spec},
+			}
+			diff, err := testResourceDiff(t, resourceRedfoxNatIp(), customizeDiffNatIpType, nil, config, nil)
+			if tc.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			attr := diff.Attributes["ip_type"]
+			if attr == nil {
+				t.Fatalf("ip_type is not planned: %v", diff.Attributes)
+			}
+			if attr.NewComputed != (tc.ipType == "") || attr.New != tc.ipType {
+				t.Errorf("ip_type is planned as %q (computed %v), want %q", attr.New, attr.NewComputed, tc.ipType)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+)
+
+// natIpCidrType returns the IP type of the network cidr. IPv4-mapped IPv6 networks are IPv6.
+func natIpCidrType(cidr string) (redfoxV1alpha1.IpType, error) {
+	network, err := parseCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	if network.Addr().Is4() {
+		return redfoxV1alpha1.Ipv4, nil
+	}
+	return redfoxV1alpha1.Ipv6, nil
+}
+
+// resolveNatIpType checks that every CIDR is of the declared IP type, or infers the IP type from the
+// CIDRs when none is declared. Invalid CIDRs are left to their own validation.
+func resolveNatIpType(declared redfoxV1alpha1.IpType, cidrs []string) (redfoxV1alpha1.IpType, error) {
+	byType := map[redfoxV1alpha1.IpType][]string{}
+	for _, cidr := range cidrs {
+		ipType, err := natIpCidrType(cidr)
+		if err != nil {
+			continue
+		}
+		byType[ipType] = append(byType[ipType], cidr)
+	}
+
+	if declared != "" {
+		var mismatched []string
+		for ipType, networks := range byType {
+			if ipType != declared {
+				mismatched = append(mismatched, networks...)
+			}
+		}
+		if len(mismatched) > 0 {
+			return "", fmt.Errorf("spec.0.cidrs must all be %s networks as declared by spec.0.ip_type, got %s", declared, strings.Join(canonicalCidrs(mismatched), ", "))
+		}
+		return declared, nil
+	}
+
+	switch {
+	case len(byType[redfoxV1alpha1.Ipv4]) > 0 && len(byType[redfoxV1alpha1.Ipv6]) > 0:
+		return "", fmt.Errorf("spec.0.cidrs mixes %s networks (%s) and %s networks (%s), so spec.0.ip_type cannot be inferred. Split them into one NatIp per IP type.",
+			redfoxV1alpha1.Ipv4, strings.Join(canonicalCidrs(byType[redfoxV1alpha1.Ipv4]), ", "),
+			redfoxV1alpha1.Ipv6, strings.Join(canonicalCidrs(byType[redfoxV1alpha1.Ipv6]), ", "))
+	case len(byType[redfoxV1alpha1.Ipv4]) > 0:
+		return redfoxV1alpha1.Ipv4, nil
+	case len(byType[redfoxV1alpha1.Ipv6]) > 0:
+		return redfoxV1alpha1.Ipv6, nil
+	}
+	return "", nil
+}
+
+// clearInferredNatIpType clears the `ip_type` of spec, the flattened `spec` of d, unless it is configured.
+// spec.ip_type is computed, so a value which is not configured was inferred and must be inferred again.
+func clearInferredNatIpType(d *schema.ResourceData, spec []any) []any {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !rawConfigValueIsNull(raw, cty.GetAttrPath("spec").IndexInt(0).GetAttr("ip_type")) {
+		return spec
+	}
+	for _, in := range spec {
+		if att, ok := in.(map[string]any); ok {
+			att["ip_type"] = ""
+		}
+	}
+	return spec
+}
+
+func natIpTypeSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Description: "IP type written to the NatIp: the explicit `spec.ip_type`, or the type of its `spec.cidrs` when omitted.",
+		Computed:    true,
+	}
+}
+
+// customizeDiffNatIpType plans the top-level `ip_type` and rejects CIDRs not matching `spec.ip_type`.
+// The type stays unknown until the CIDRs are known. CIDRs are classified in the canonical notation
+// they are sent in, as during apply.
+func customizeDiffNatIpType(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.GetRawConfig().IsNull() {
+		return nil
+	}
+	// spec.ip_type is computed, so only the configuration tells whether it was declared
+	rawIpType, err := cty.GetAttrPath("spec").IndexInt(0).GetAttr("ip_type").Apply(d.GetRawConfig())
+	if !d.NewValueKnown("spec.0.cidrs") || (err == nil && !rawIpType.IsKnown()) {
+		return d.SetNewComputed("ip_type")
+	}
+	var declared redfoxV1alpha1.IpType
+	if err == nil && !rawIpType.IsNull() {
+		declared = redfoxV1alpha1.IpType(rawIpType.AsString())
+	}
+	cidrs := canonicalCidrs(sliceOfString(d.Get("spec.0.cidrs").(*schema.Set).List()))
+	ipType, err := resolveNatIpType(declared, cidrs)
+	if err != nil {
+		return err
+	}
+	return d.SetNew("ip_type", string(ipType))
+}
+
+// natIpCidrsByTypeSchema returns the schema of the `ipv4_cidrs` and `ipv6_cidrs` of the data sources.
+func natIpCidrsByTypeSchema(ipType redfoxV1alpha1.IpType) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: fmt.Sprintf("The %s networks of `cidrs`, sorted.", ipType),
+		Computed:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// flattenNatIpCidrsByType adds the `ipv4_cidrs` and `ipv6_cidrs` of the data sources to a flattened spec.
+func flattenNatIpCidrsByType(spec []any, in redfoxV1alpha1.NatIpSpec) []any {
+	ipv4, ipv6 := []string{}, []string{}
+	for _, cidr := range canonicalCidrs(in.Cidrs) {
+		switch ipType, _ := natIpCidrType(cidr); ipType {
+		case redfoxV1alpha1.Ipv4:
+			ipv4 = append(ipv4, cidr)
+		case redfoxV1alpha1.Ipv6:
+			ipv6 = append(ipv6, cidr)
+		}
+	}
+	for _, raw := range spec {
+		att := raw.(map[string]any)
+		att["ipv4_cidrs"] = ipv4
+		att["ipv6_cidrs"] = ipv6
+	}
+	return spec
+}
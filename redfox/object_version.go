@@ -0,0 +1,57 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Nested attributes such as `metadata.0.resource_version` cannot be marked as unknown during plan,
+// so resources mirror them at the top level where CustomizeDiff is allowed to do so.
+
+func resourceVersionSchema(objectName string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Description: fmt.Sprintf("Same as `metadata.0.resource_version`, but known to change in the plan whenever the %s is updated. Reference this attribute to trigger on changes.", objectName),
+		Computed:    true,
+	}
+}
+
+func generationSchema(objectName string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeInt,
+		Description: fmt.Sprintf("Same as `metadata.0.generation`, but known to change in the plan whenever the %s is updated. Reference this attribute to trigger on changes.", objectName),
+		Computed:    true,
+	}
+}
+
+// customizeDiffObjectVersion marks `resource_version` and `generation` as unknown when any of keys,
+// or the labels or annotations, have a planned change. New resources need nothing, computed
+// attributes without state are unknown anyway.
+func customizeDiffObjectVersion(keys ...string) schema.CustomizeDiffFunc {
+	keys = append(keys, "metadata.0.labels", "metadata.0.annotations")
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if d.Id() == "" {
+			return nil
+		}
+		for _, key := range keys {
+			if !d.HasChange(key) {
+				continue
+			}
+			if err := d.SetNewComputed("resource_version"); err != nil {
+				return err
+			}
+			return d.SetNewComputed("generation")
+		}
+		return nil
+	}
+}
+
+func setObjectVersion(d *schema.ResourceData, om metav1.ObjectMeta) error {
+	if err := d.Set("resource_version", om.ResourceVersion); err != nil {
+		return err
+	}
+	return d.Set("generation", int(om.Generation))
+}
@@ -0,0 +1,156 @@
+package redfox
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+const oidcRequestTimeout = 10 * time.Second
+
+// oidcDiscoveryDocument holds the fields of an OpenID Provider configuration used to verify issuers.
+type oidcDiscoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JwksURI string `json:"jwks_uri"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// usableForSignatures reports why the key cannot verify service account tokens, or "" if it can.
+func (k jsonWebKey) usableForSignatures() string {
+	if k.Use != "" && k.Use != "sig" {
+		return fmt.Sprintf("use is %q", k.Use)
+	}
+	decodes := func(values ...string) bool {
+		for _, v := range values {
+			if b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(v, "=")); err != nil || len(b) == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	switch k.Kty {
+	case "RSA":
+		if !decodes(k.N, k.E) {
+			return "RSA modulus or exponent is missing or not base64url encoded"
+		}
+	case "EC":
+		if k.Crv != "P-256" && k.Crv != "P-384" && k.Crv != "P-521" {
+			return fmt.Sprintf("unsupported curve %q", k.Crv)
+		}
+		if !decodes(k.X, k.Y) {
+			return "EC coordinates are missing or not base64url encoded"
+		}
+	default:
+		return fmt.Sprintf("unsupported key type %q", k.Kty)
+	}
+	return ""
+}
+
+// providerHTTPTransport returns a transport which goes through the proxy of the provider.
+func providerHTTPTransport(meta interface{}) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if m, ok := meta.(kubeClientsets); ok && m.config != nil && m.config.Proxy != nil {
+		transport.Proxy = m.config.Proxy
+	}
+	return transport
+}
+
+// oidcHTTPClient returns a client for OIDC discovery which goes through the proxy of the provider.
+func oidcHTTPClient(meta interface{}) *http.Client {
+	return &http.Client{Transport: providerHTTPTransport(meta), Timeout: oidcRequestTimeout}
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("GET %s returned invalid JSON: %s", url, err)
+	}
+	return nil
+}
+
+// verifyOidcIssuer fetches the OIDC discovery document of issuer and the JWKS it references, and
+// checks that the document advertises exactly issuer and that at least one key can verify tokens.
+func verifyOidcIssuer(ctx context.Context, client *http.Client, issuer string) diag.Diagnostics {
+	path := cty.GetAttrPath("status").IndexInt(0).GetAttr("service_account_issuer")
+	failure := func(summary, detail string) diag.Diagnostics {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       summary,
+			Detail:        detail,
+			AttributePath: path,
+		}}
+	}
+
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	tflog.Info(ctx, fmt.Sprintf("Verifying service account issuer %s", discoveryURL))
+
+	var discovery oidcDiscoveryDocument
+	if err := getJSON(ctx, client, discoveryURL, &discovery); err != nil {
+		return failure("Failed to fetch the OIDC discovery document of the service account issuer", err.Error())
+	}
+	if discovery.Issuer != issuer {
+		return failure("Service account issuer does not match its OIDC discovery document",
+			fmt.Sprintf("%s advertises issuer %q, but status.service_account_issuer is %q. Tokens would be rejected by relying parties such as AWS STS.", discoveryURL, discovery.Issuer, issuer))
+	}
+	if discovery.JwksURI == "" {
+		return failure("OIDC discovery document of the service account issuer has no jwks_uri", fmt.Sprintf("%s does not reference a JSON Web Key Set.", discoveryURL))
+	}
+
+	var keys jsonWebKeySet
+	if err := getJSON(ctx, client, discovery.JwksURI, &keys); err != nil {
+		return failure("Failed to fetch the JSON Web Key Set of the service account issuer", err.Error())
+	}
+	var problems []string
+	for i, key := range keys.Keys {
+		problem := key.usableForSignatures()
+		if problem == "" {
+			return nil
+		}
+		problems = append(problems, fmt.Sprintf("key %d (kid %q): %s", i, key.Kid, problem))
+	}
+	detail := fmt.Sprintf("%s contains no key able to verify service account tokens.", discovery.JwksURI)
+	if len(problems) > 0 {
+		detail += "\n  - " + strings.Join(problems, "\n  - ")
+	}
+	return failure("Service account issuer has no usable signing key", detail)
+}
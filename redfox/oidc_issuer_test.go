@@ -0,0 +1,112 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+const testJwks = `{"keys": [{"kty": "RSA", "use": "sig", "kid": "1", "alg": "RS256", "n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw", "e": "AQAB"}]}`
+
+// testOidcIssuer serves the OIDC discovery document returned by discovery, given the issuer URL, and
+// the JWKS of jwks.
+func testOidcIssuer(t *testing.T, discovery func(issuer string) (int, string), jwks string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			status, body := discovery(server.URL)
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+		case "/keys":
+			fmt.Fprint(w, jwks)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVerifyOidcIssuer(t *testing.T) {
+	document := func(issuer string) (int, string) {
+		return http.StatusOK, fmt.Sprintf(`{"issuer": %q, "jwks_uri": %q}`, issuer, issuer+"/keys")
+	}
+	cases := []struct {
+		name      string
+		discovery func(issuer string) (int, string)
+		jwks      string
+		summary   string
+		detail    string
+	}{
+		{
+			name:      "matching issuer",
+			discovery: document,
+			jwks:      testJwks,
+		},
+		{
+			name: "mismatched issuer",
+			discovery: func(issuer string) (int, string) {
+				_, body := document("https://oidc.example.com")
+				return http.StatusOK, body
+			},
+			jwks:    testJwks,
+			summary: "Service account issuer does not match its OIDC discovery document",
+			detail:  `advertises issuer "https://oidc.example.com"`,
+		},
+		{
+			name: "non-200",
+			discovery: func(issuer string) (int, string) {
+				return http.StatusInternalServerError, "unavailable"
+			},
+			summary: "Failed to fetch the OIDC discovery document of the service account issuer",
+			detail:  "returned 500 Internal Server Error",
+		},
+		{
+			name: "malformed document",
+			discovery: func(issuer string) (int, string) {
+				return http.StatusOK, `{"issuer": `
+			},
+			summary: "Failed to fetch the OIDC discovery document of the service account issuer",
+			detail:  "returned invalid JSON",
+		},
+		{
+			name: "missing jwks_uri",
+			discovery: func(issuer string) (int, string) {
+				return http.StatusOK, fmt.Sprintf(`{"issuer": %q}`, issuer)
+			},
+			summary: "OIDC discovery document of the service account issuer has no jwks_uri",
+		},
+		{
+			name:      "no usable key",
+			discovery: document,
+			jwks:      `{"keys": [{"kty": "RSA", "use": "enc", "kid": "1", "n": "AQAB", "e": "AQAB"}, {"kty": "oct", "kid": "2"}]}`,
+			summary:   "Service account issuer has no usable signing key",
+			detail:    "  - key 0 (kid \"1\"): use is \"enc\"\n  - key 1 (kid \"2\"): unsupported key type \"oct\"",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := testOidcIssuer(t, tc.discovery, tc.jwks)
+			diags := verifyOidcIssuer(context.Background(), server.Client(), server.URL)
+			if tc.summary == "" {
+				if len(diags) > 0 {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+			if len(diags) != 1 {
+				t.Fatalf("expected one diagnostic, got %v", diags)
+			}
+			if diags[0].Severity != diag.Error || diags[0].Summary != tc.summary || !strings.Contains(diags[0].Detail, tc.detail) {
+				t.Fatalf("expected an error %q with a detail containing %q, got %q: %q", tc.summary, tc.detail, diags[0].Summary, diags[0].Detail)
+			}
+		})
+	}
+}
@@ -5,8 +5,23 @@
 	"reflect"
 	"sort"
 	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// marshalApplyPatch serializes obj as a server-side apply patch without the given top level fields,
+// so that the field manager never claims ownership of them.
+func marshalApplyPatch(obj interface{}, omitFields ...string) ([]byte, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range omitFields {
+		delete(content, field)
+	}
+	return json.Marshal(content)
+}
+
 func diffStringMap(pathPrefix string, oldV, newV map[string]interface{}) PatchOperations {
 	ops := make([]PatchOperation, 0, 0)
 
@@ -9,6 +9,7 @@
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -29,7 +30,10 @@
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-const defaultFieldManagerName = "TerraformRedFox"
+const (
+	defaultFieldManagerName = "TerraformRedFox"
+	defaultNamespace        = "default"
+)
 
 func Provider() *schema.Provider {
 	p := &schema.Provider{
@@ -200,13 +204,79 @@ func Provider() *schema.Provider {
 				Optional:    true,
 				Description: "List of Kubernetes metadata labels to ignore across all resources handled by this provider for situations where external systems are managing certain resource labels. Each item is a regular expression.",
 			},
+			"ca_cert_expiry_warning": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      defaultCaCertExpiryWarning.String(),
+				ValidateFunc: validateDuration,
+				Description:  "Warn when a certificate in `status.apiserver.ca_cert` of a cluster expires within this duration, e.g. `720h`. `0s` disables the warning.",
+			},
+			"naming": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Naming convention of clusters.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cluster_name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateNamingTemplate,
+							Description:  "Go template computing `spec.cluster_name` of clusters which omit it, and which explicit names must match, e.g. `{{.ServicePhase}}-{{.ServiceTag}}-{{.InfraVendor | lower}}`. Fields: `Namespace`, `ClusterGroup`, `ClusterEngine`, `ClusterRegion`, `InfraVendor`, `InfraAccountId`, `ServicePhase`, `ServiceTag`. Functions: `lower`, `upper`, `trim`, `trimPrefix`, `trimSuffix`, `replace`, `truncate`.",
+						},
+					},
+				},
+			},
+			"cluster_name_uniqueness": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      clusterNameUniquenessNamespace,
+				ValidateFunc: validation.StringInSlice([]string{clusterNameUniquenessNamespace, clusterNameUniquenessCluster, clusterNameUniquenessNone}, false),
+				Description:  "Scope in which `spec.cluster_name` of clusters must be unique, checked at plan time: `namespace`, `cluster` (all namespaces) or `none` to disable the check, e.g. when the provider cannot list clusters during plan.",
+			},
+			"allowed_cluster_roles": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotWhiteSpace,
+				},
+				Optional:    true,
+				Description: "List of roles accepted in `spec.roles` of clusters, replacing the roles known to this provider release. Use it when the redfox API adds new roles.",
+			},
+			"extra_regions": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Private regions accepted as `cluster_region` of clusters of an `infra_vendor`, in addition to the built-in catalog of the vendor. The `on-prem` catalog is empty since on-premises regions are private to each installation: the regions of `on-prem` clusters are not validated until they are listed here.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"infra_vendor": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(knownInfraVendors(), true),
+							Description:  "Vendor of the regions, e.g. `on-prem`.",
+						},
+						"regions": {
+							Type:     schema.TypeList,
+							Required: true,
+							MinItems: 1,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.StringIsNotWhiteSpace,
+							},
+							Description: "Regions accepted for `infra_vendor`.",
+						},
+					},
+				},
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"redfox_natip":    dataSourceRedfoxNatIp(),
-			"redfox_natips":   dataSourceRedfoxNatIps(),
-			"redfox_cluster":  dataSourceRedfoxCluster(),
-			"redfox_clusters": dataSourceRedfoxClusters(),
+			"redfox_natip":                dataSourceRedfoxNatIp(),
+			"redfox_natips":               dataSourceRedfoxNatIps(),
+			"redfox_cluster":              dataSourceRedfoxCluster(),
+			"redfox_clusters":             dataSourceRedfoxClusters(),
+			"redfox_cluster_trust_policy": dataSourceRedfoxClusterTrustPolicy(),
+			"redfox_kubeconfig":           dataSourceRedfoxKubeconfig(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
@@ -241,6 +311,16 @@ type kubeClientsets struct {
 
 	IgnoreAnnotations []string
 	IgnoreLabels      []string
+	// ExtraRegions extends the region catalog, keyed by lower-cased infra_vendor
+	ExtraRegions map[string][]string
+	// AllowedClusterRoles replaces defaultClusterRoles when not empty
+	AllowedClusterRoles []string
+	// ClusterNameUniqueness is the scope of the plan-time uniqueness check of cluster names
+	ClusterNameUniqueness string
+	// ClusterNameTemplate computes and validates the spec.cluster_name of clusters when not empty
+	ClusterNameTemplate string
+	// CaCertExpiryWarning is how long before their expiry CA certificates of clusters are warned about
+	CaCertExpiryWarning time.Duration
 }
 
 func (k kubeClientsets) MainClientset() (*kubernetes.Clientset, error) {
@@ -328,6 +408,12 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData, terraformVer
 
 	ignoreAnnotations := []string{}
 	ignoreLabels := []string{}
+	allowedClusterRoles := []string{}
+	clusterNameTemplate := ""
+	caCertExpiryWarning, err := time.ParseDuration(d.Get("ca_cert_expiry_warning").(string))
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
 
 	if v, ok := d.Get("ignore_annotations").([]interface{}); ok {
 		ignoreAnnotations = expandStringSlice(v)
@@ -335,15 +421,26 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData, terraformVer
 	if v, ok := d.Get("ignore_labels").([]interface{}); ok {
 		ignoreLabels = expandStringSlice(v)
 	}
+	if v, ok := d.Get("allowed_cluster_roles").([]interface{}); ok {
+		allowedClusterRoles = expandStringSlice(v)
+	}
+	if v, ok := d.Get("naming").([]interface{}); ok && len(v) > 0 && v[0] != nil {
+		clusterNameTemplate = v[0].(map[string]interface{})["cluster_name"].(string)
+	}
 
 	m := kubeClientsets{
-		config:            cfg,
-		mainClientset:     nil,
-		dynamicClient:     nil,
-		discoveryClient:   nil,
-		redfoxClient:      nil,
-		IgnoreAnnotations: ignoreAnnotations,
-		IgnoreLabels:      ignoreLabels,
+		config:                cfg,
+		mainClientset:         nil,
+		dynamicClient:         nil,
+		discoveryClient:       nil,
+		redfoxClient:          nil,
+		IgnoreAnnotations:     ignoreAnnotations,
+		IgnoreLabels:          ignoreLabels,
+		ExtraRegions:          expandExtraRegions(d.Get("extra_regions").([]interface{})),
+		AllowedClusterRoles:   allowedClusterRoles,
+		ClusterNameUniqueness: d.Get("cluster_name_uniqueness").(string),
+		ClusterNameTemplate:   clusterNameTemplate,
+		CaCertExpiryWarning:   caCertExpiryWarning,
 	}
 	return m, diag.Diagnostics{}
 }
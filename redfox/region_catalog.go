@@ -0,0 +1,175 @@
+package redfox
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const infraVendorOnPrem = "on-prem"
+
+// regionCatalogFiles holds one file per vendor, named after the lower-cased `infra_vendor`, listing
+// a region per line. Lines starting with `#` are comments.
+//
+//go:embed regions/*.txt
+var regionCatalogFiles embed.FS
+
+var regionCatalog = mustLoadRegionCatalog()
+
+func mustLoadRegionCatalog() map[string][]string {
+	entries, err := regionCatalogFiles.ReadDir("regions")
+	if err != nil {
+		panic(err)
+	}
+	catalog := map[string][]string{}
+	for _, entry := range entries {
+		f, err := regionCatalogFiles.Open("regions/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		vendor := strings.TrimSuffix(entry.Name(), ".txt")
+		regions := []string{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			regions = append(regions, line)
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			panic(err)
+		}
+		catalog[vendor] = regions
+	}
+	return catalog
+}
+
+// knownRegions returns the regions of vendor in the embedded catalog and the provider `extra_regions`
+// extra, and whether the regions of the vendor are validated at all. Vendors missing from the catalog
+// are not validated. The on-prem catalog is deliberately empty: on-premises regions are private to
+// each installation, so they are free-form until `extra_regions` lists some for on-prem.
+func knownRegions(vendor string, extra map[string][]string) ([]string, bool) {
+	vendor = strings.ToLower(vendor)
+	regions, ok := regionCatalog[vendor]
+	if !ok {
+		return nil, false
+	}
+	if vendor == infraVendorOnPrem && len(extra[vendor]) == 0 {
+		return nil, false
+	}
+	return append(append([]string{}, regions...), extra[vendor]...), true
+}
+
+// expandExtraRegions returns the regions of the provider `extra_regions` blocks, keyed by lower-cased
+// `infra_vendor`.
+func expandExtraRegions(l []interface{}) map[string][]string {
+	extra := map[string][]string{}
+	for _, raw := range l {
+		in, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		vendor := strings.ToLower(in["infra_vendor"].(string))
+		extra[vendor] = append(extra[vendor], expandStringSlice(in["regions"].([]interface{}))...)
+	}
+	return extra
+}
+
+// suggestRegions returns up to three regions close to region, closest first.
+func suggestRegions(region string, regions []string) []string {
+	normalized := strings.ToLower(strings.TrimSpace(region))
+	type candidate struct {
+		region   string
+		distance int
+	}
+	var candidates []candidate
+	for _, r := range regions {
+		distance := levenshteinDistance(normalized, r)
+		if distance <= 3 {
+			candidates = append(candidates, candidate{r, distance})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].region < candidates[j].region
+	})
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		suggestions = append(suggestions, candidates[i].region)
+	}
+	return suggestions
+}
+
+func levenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// customizeDiffClusterRegion rejects a `cluster_region` missing from the catalog of the `infra_vendor`
+// of the Cluster, extended by the provider `extra_regions`.
+func customizeDiffClusterRegion(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("spec.0.infra_vendor") || !d.NewValueKnown("spec.0.cluster_region") {
+		return nil
+	}
+	vendor := d.Get("spec.0.infra_vendor").(string)
+	region := d.Get("spec.0.cluster_region").(string)
+
+	var extra map[string][]string
+	if m, ok := meta.(kubeClientsets); ok {
+		extra = m.ExtraRegions
+	}
+	regions, ok := knownRegions(vendor, extra)
+	if !ok {
+		return nil
+	}
+	for _, r := range regions {
+		if r == region {
+			return nil
+		}
+	}
+
+	message := fmt.Sprintf("spec.0.cluster_region %q is not a known region of infra_vendor %q.", region, vendor)
+	if suggestions := suggestRegions(region, regions); len(suggestions) > 0 {
+		quoted := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			quoted[i] = fmt.Sprintf("%q", s)
+		}
+		message += fmt.Sprintf(" Did you mean %s?", strings.Join(quoted, " or "))
+	}
+	return fmt.Errorf("%s Private regions can be allowed with an `extra_regions` block of the provider for infra_vendor %q.", message, strings.ToLower(vendor))
+}
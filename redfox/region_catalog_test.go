@@ -0,0 +1,57 @@
+package redfox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKnownRegions(t *testing.T) {
+	cases := []struct {
+		name      string
+		vendor    string
+		extra     map[string][]string
+		region    string
+		want      bool
+		validated bool
+	}{
+		{name: "catalog region", vendor: infraVendorAws, region: "ap-northeast-2", want: true, validated: true},
+		{name: "unknown region", vendor: infraVendorAws, region: "mars-1", validated: true},
+		{name: "vendor is case-insensitive", vendor: "AWS", region: "ap-northeast-2", want: true, validated: true},
+		{name: "extra region of the vendor", vendor: infraVendorAws, extra: map[string][]string{infraVendorAws: {"mars-1"}}, region: "mars-1", want: true, validated: true},
+		{name: "extra region of another vendor", vendor: infraVendorAws, extra: map[string][]string{infraVendorGcp: {"mars-1"}}, region: "mars-1", validated: true},
+		{name: "on-prem without extra regions", vendor: infraVendorOnPrem, region: "seoul-idc"},
+		{name: "on-prem extra region", vendor: infraVendorOnPrem, extra: map[string][]string{infraVendorOnPrem: {"seoul-idc"}}, region: "seoul-idc", want: true, validated: true},
+		{name: "on-prem unlisted region", vendor: infraVendorOnPrem, extra: map[string][]string{infraVendorOnPrem: {"seoul-idc"}}, region: "tokyo-idc", validated: true},
+		{name: "unknown vendor", vendor: "openstack", region: "anything"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			regions, validated := knownRegions(c.vendor, c.extra)
+			if validated != c.validated {
+				t.Fatalf("validated = %v, want %v", validated, c.validated)
+			}
+			found := false
+			for _, region := range regions {
+				found = found || region == c.region
+			}
+			if found != c.want {
+				t.Errorf("%q in regions = %v, want %v", c.region, found, c.want)
+			}
+		})
+	}
+}
+
+func TestExpandExtraRegions(t *testing.T) {
+	got := expandExtraRegions([]interface{}{
+		map[string]interface{}{"infra_vendor": "on-prem", "regions": []interface{}{"seoul-idc"}},
+		map[string]interface{}{"infra_vendor": "aws", "regions": []interface{}{"mars-1"}},
+		map[string]interface{}{"infra_vendor": "On-Prem", "regions": []interface{}{"tokyo-idc"}},
+	})
+	want := map[string][]string{
+		infraVendorOnPrem: {"seoul-idc", "tokyo-idc"},
+		infraVendorAws:    {"mars-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandExtraRegions() = %v, want %v", got, want)
+	}
+}
@@ -2,16 +2,19 @@
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -25,13 +28,52 @@ func resourceRedfoxCluster() *schema.Resource {
 		UpdateContext: resourceRedfoxClusterApply,
 		DeleteContext: resourceRedfoxClusterDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceRedfoxClusterImportLookup().StateContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
+			Create:  schema.DefaultTimeout(5 * time.Minute),
+			Update:  schema.DefaultTimeout(5 * time.Minute),
 			Default: schema.DefaultTimeout(30 * time.Second),
 		},
+		SchemaVersion: 3,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceRedfoxClusterV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxClusterStateUpgradeV0,
+			},
+			{
+				Version: 1,
+				Type:    resourceRedfoxClusterV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxClusterStateUpgradeV1,
+			},
+			{
+				Version: 2,
+				Type:    resourceRedfoxClusterV2().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxClusterStateUpgradeV2,
+			},
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffClusterName,
+			customizeDiffIdentityChange(clusterIdentityKeys),
+			customizeDiffClusterVendorRules,
+			customizeDiffClusterRegion,
+			customizeDiffClusterRoles,
+			customizeDiffClusterNameUniqueness,
+			customizeDiffObjectVersion("spec"),
+		),
 		Schema: map[string]*schema.Schema{
-			"metadata": namespacedMetadataSchema("cluster", true),
+			"resource_version": resourceVersionSchema("cluster"),
+			"generation":       generationSchema("cluster"),
+			"metadata":         namespacedMetadataSchema("cluster", true),
+			"on_uid_change":    onUidChangeSchema("cluster"),
+			"wait_for":         waitForSchema("cluster"),
+			"identity_change":  identityChangeSchema("cluster"),
+			"cluster_name": {
+				Type:        schema.TypeString,
+				Description: "Name of the cluster as written to `spec.cluster_name`: the explicit value, or the rendering of the `naming` template of the provider. Known at plan time when its inputs are.",
+				Computed:    true,
+			},
 			"spec": {
 				Type:        schema.TypeList,
 				Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
@@ -41,9 +83,10 @@ func resourceRedfoxCluster() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"cluster_name": {
-							Description:      "",
+							Description:      "Name of the cluster. Computed from the `naming` template of the provider when omitted.",
 							Type:             schema.TypeString,
-							Required:         true,
+							Optional:         true,
+							Computed:         true,
 							ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
 						},
 						"cluster_region": {
@@ -80,7 +123,7 @@ func resourceRedfoxCluster() *schema.Resource {
 							Description:      "",
 							Type:             schema.TypeString,
 							Required:         true,
-							ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+							ValidateDiagFunc: validation.ToDiagFunc(validation.All(validation.StringIsNotEmpty, validateInfraVendor)),
 						},
 						"infra_account_id": {
 							Description:      "",
@@ -88,15 +131,7 @@ func resourceRedfoxCluster() *schema.Resource {
 							Required:         true,
 							ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
 						},
-						"roles": {
-							Description: "",
-							Type:        schema.TypeList,
-							Required:    true,
-							Elem: &schema.Schema{
-								Type:             schema.TypeString,
-								ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{string(redfoxV1alpha1.ClusterRoleIngame), string(redfoxV1alpha1.ClusterRoleOutgame), string(redfoxV1alpha1.ClusterRoleCentral)}, false)),
-							},
-						},
+						"roles": clusterRolesSchema(),
 						"vpc_id": {
 							Description:      "",
 							Type:             schema.TypeString,
@@ -115,6 +150,7 @@ func resourceRedfoxCluster() *schema.Resource {
 					},
 				},
 			},
+			"status": clusterStatusComputedSchema(),
 		},
 	}
 }
@@ -137,6 +173,11 @@ func resourceRedfoxClusterApply(ctx context.Context, d *schema.ResourceData, met
 		return diag.FromErr(err)
 	}
 
+	// Holds the explicit spec.cluster_name, or the name computed from the naming template
+	if name, ok := d.Get("cluster_name").(string); ok && name != "" {
+		spec.ClusterName = name
+	}
+
 	cluster := &redfoxV1alpha1.Cluster{
 		TypeMeta:   clusterTypeMeta,
 		ObjectMeta: metadata,
@@ -145,27 +186,89 @@ func resourceRedfoxClusterApply(ctx context.Context, d *schema.ResourceData, met
 
 	tflog.Info(ctx, fmt.Sprintf("Apply %s: %#v", clusterKind.Kind, cluster))
 
-	buf, err := json.Marshal(cluster)
+	// Status is owned by redfox_cluster_status and must never be written from here
+	buf, err := marshalApplyPatch(cluster, "status")
 	if err != nil {
 		tflog.Debug(ctx, fmt.Sprintf("%s Marshal error: %#v", clusterKind.Kind, err))
 		return diag.FromErr(err)
 	}
 	out, err := conn.MetadataV1alpha1().Clusters(cluster.Namespace).Patch(ctx, cluster.Name, types.ApplyPatchType, buf, metav1.PatchOptions{FieldManager: defaultFieldManagerName})
 	if err != nil {
-		return diag.Errorf(fmt.Sprintf("Failed to create %s: %#v", clusterKind.Kind, err))
+		return kubeErrorDiagnostics(err, "apply", clusterKind.Kind, resourceRedfoxCluster().Schema)
 	}
 
 	d.SetId(buildId(out.ObjectMeta))
 
 	tflog.Info(ctx, fmt.Sprintf("Submitted new %s: %#v", clusterKind.Kind, out))
 
+	if fields := expandWaitForFields(d.Get("wait_for").([]interface{})); len(fields) > 0 {
+		timeout := d.Timeout(schema.TimeoutUpdate)
+		if d.IsNewResource() {
+			timeout = d.Timeout(schema.TimeoutCreate)
+		}
+		diags := waitForCluster(ctx, d, meta, out.Namespace, out.Name, fields, timeout)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
 	return resourceRedfoxClusterRead(ctx, d, meta)
 }
 
+func waitForCluster(ctx context.Context, d *schema.ResourceData, meta interface{}, namespace, name string, fields map[string]string, timeout time.Duration) diag.Diagnostics {
+	conn, err := meta.(KubeClientsets).RedfoxClient()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("Waiting for %s %s/%s: %v", clusterKind.Kind, namespace, name, fields))
+
+	var unmet []string
+	var getErr error
+	err = resource.RetryContext(ctx, timeout, func() *resource.RetryError {
+		// Only the conditions of the last observed Cluster are reported
+		unmet = nil
+		cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			getErr = err
+			return resource.NonRetryableError(err)
+		}
+		unmet, err = unmetWaitConditions(cluster, fields)
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+		if len(unmet) > 0 {
+			return resource.RetryableError(fmt.Errorf("%s %s/%s does not satisfy %d condition(s) yet", clusterKind.Kind, namespace, name, len(unmet)))
+		}
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+	if getErr != nil {
+		return kubeErrorDiagnostics(getErr, "wait for", clusterKind.Kind, nil)
+	}
+	if len(unmet) > 0 {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("Timed out waiting for %s %s/%s after %s", clusterKind.Kind, namespace, name, timeout),
+			Detail:        fmt.Sprintf("The following `wait_for` conditions were still unmet:\n  - %s", strings.Join(unmet, "\n  - ")),
+			AttributePath: cty.GetAttrPath("wait_for"),
+		}}
+	}
+	return kubeErrorDiagnostics(err, "wait for", clusterKind.Kind, nil)
+}
+
 func resourceRedfoxClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return readRedfoxCluster(ctx, d, meta, false)
+}
+
+// readRedfoxCluster reads the Cluster of d into d. The redfox_cluster data source reuses it with dataSource set, to
+// skip the attributes only the resource has.
+func readRedfoxCluster(ctx context.Context, d *schema.ResourceData, meta interface{}, dataSource bool) diag.Diagnostics {
 	exists, err := resourceRedfoxClusterExists(ctx, d, meta)
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
 	}
 	if !exists {
 		d.SetId("")
@@ -186,15 +289,30 @@ func resourceRedfoxClusterRead(ctx context.Context, d *schema.ResourceData, meta
 	cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		tflog.Debug(ctx, fmt.Sprintf("Received error: %#v", err))
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
 	}
 	tflog.Info(ctx, fmt.Sprintf("Received %s: %#v", clusterKind.Kind, cluster))
 
+	var diags diag.Diagnostics
+	if !dataSource {
+		var recreated bool
+		recreated, diags = checkUidChange(d, clusterKind.Kind, cluster.ObjectMeta)
+		if recreated {
+			d.SetId("")
+			return diags
+		}
+	}
+
 	err = d.Set("metadata", flattenMetadata(cluster.ObjectMeta, d, meta))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	err = setObjectVersion(d, cluster.ObjectMeta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	spec, err := flattenClusterSpec(cluster.Spec, d, meta)
 	if err != nil {
 		return diag.FromErr(err)
@@ -204,7 +322,24 @@ func resourceRedfoxClusterRead(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	return nil
+
+	if !dataSource {
+		err = d.Set("cluster_name", cluster.Spec.ClusterName)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	status, err := flattenClusterStatus(cluster.Status, d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set("status", status)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diags
 }
 
 func resourceRedfoxClusterExists(ctx context.Context, d *schema.ResourceData, meta interface{}) (bool, error) {
@@ -244,7 +379,7 @@ func resourceRedfoxClusterDelete(ctx context.Context, d *schema.ResourceData, me
 
 	err = conn.MetadataV1alpha1().Clusters(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "delete", clusterKind.Kind, nil)
 	}
 
 	err = resource.RetryContext(ctx, d.Timeout(schema.TimeoutDelete), func() *resource.RetryError {
@@ -260,7 +395,7 @@ func resourceRedfoxClusterDelete(ctx context.Context, d *schema.ResourceData, me
 		return resource.RetryableError(e)
 	})
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "delete", clusterKind.Kind, nil)
 	}
 
 	tflog.Info(ctx, fmt.Sprintf("%s %s deleted", clusterKind.Kind, name))
@@ -268,3 +403,53 @@ func resourceRedfoxClusterDelete(ctx context.Context, d *schema.ResourceData, me
 	d.SetId("")
 	return nil
 }
+
+func resourceRedfoxClusterImportLookup() importLookup {
+	return importLookup{
+		kind: clusterKind,
+		get: func(ctx context.Context, meta interface{}, namespace, name string) (*metav1.ObjectMeta, error) {
+			conn, err := meta.(KubeClientsets).RedfoxClient()
+			if err != nil {
+				return nil, err
+			}
+			cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return &cluster.ObjectMeta, nil
+		},
+		list:              listClusterMetadata,
+		listByClusterName: listClusterMetadataByClusterName,
+	}
+}
+
+func listClusterMetadata(ctx context.Context, meta interface{}, namespace string) ([]metav1.ObjectMeta, error) {
+	conn, err := meta.(KubeClientsets).RedfoxClient()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := conn.MetadataV1alpha1().Clusters(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map[redfoxV1alpha1.Cluster, metav1.ObjectMeta](clusters.Items, func(x redfoxV1alpha1.Cluster, _ int) metav1.ObjectMeta {
+		return x.ObjectMeta
+	}), nil
+}
+
+func listClusterMetadataByClusterName(ctx context.Context, meta interface{}, namespace, clusterName string) ([]metav1.ObjectMeta, error) {
+	conn, err := meta.(KubeClientsets).RedfoxClient()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := conn.MetadataV1alpha1().Clusters(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	matches := lo.Filter[redfoxV1alpha1.Cluster](clusters.Items, func(x redfoxV1alpha1.Cluster, _ int) bool {
+		return x.Spec.ClusterName == clusterName
+	})
+	return lo.Map[redfoxV1alpha1.Cluster, metav1.ObjectMeta](matches, func(x redfoxV1alpha1.Cluster, _ int) metav1.ObjectMeta {
+		return x.ObjectMeta
+	}), nil
+}
@@ -2,33 +2,76 @@
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	redfoxClient "github.com/krafton-hq/redfox/pkg/generated/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 )
 
 func resourceRedfoxClusterStatus() *schema.Resource {
 	return &schema.Resource{
+		Description:   "Writes the status of a Cluster with server-side apply. Writes racing other writes of the Cluster, and transient errors of the API server, are retried with backoff. Conflicts with another field manager owning fields of the status are not retried and fail listing the other field managers.",
 		CreateContext: resourceRedfoxClusterStatusApply,
 		ReadContext:   resourceRedfoxClusterStatusRead,
 		UpdateContext: resourceRedfoxClusterStatusApply,
 		DeleteContext: resourceRedfoxClusterStatusDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceRedfoxClusterStatusImportLookup().StateContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
+			Create:  schema.DefaultTimeout(5 * time.Minute),
+			Update:  schema.DefaultTimeout(5 * time.Minute),
 			Default: schema.DefaultTimeout(30 * time.Second),
 		},
+		SchemaVersion: 3,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceRedfoxClusterStatusV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxClusterStatusStateUpgradeV0,
+			},
+			{
+				Version: 1,
+				Type:    resourceRedfoxClusterStatusV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxClusterStatusStateUpgradeV1,
+			},
+			{
+				Version: 2,
+				Type:    resourceRedfoxClusterStatusV2().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxClusterStatusStateUpgradeV2,
+			},
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffApiserver,
+			customizeDiffAwsIamIdps,
+			customizeDiffObjectVersion("status", "apiserver"),
+		),
 		Schema: map[string]*schema.Schema{
-			"metadata": namespacedMetadataSchema("cluster", false),
+			"resource_version": resourceVersionSchema("cluster"),
+			"generation":       generationSchema("cluster"),
+			"metadata":         namespacedMetadataSchema("cluster", false),
+			"verify_issuer": {
+				Type:        schema.TypeBool,
+				Description: "Before writing the status, fetch the OIDC discovery document of `service_account_issuer` and its JSON Web Key Set, and fail unless the advertised issuer matches exactly and a key can verify tokens. Requests go through the provider `proxy_url`.",
+				Optional:    true,
+			},
+			"verify_endpoint": verifyEndpointSchema(),
+			"from_kubeconfig": fromKubeconfigSchema(),
+			"apiserver":       apiserverComputedSchema(),
 			"status": {
 				Type:        schema.TypeList,
 				Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
@@ -46,17 +89,21 @@ func resourceRedfoxClusterStatus() *schema.Resource {
 							Elem: &schema.Resource{
 								Schema: map[string]*schema.Schema{
 									"endpoint": {
-										Description:      "",
+										Description:      "URL of the Kubernetes API server. Read from `from_kubeconfig` when omitted.",
 										Type:             schema.TypeString,
-										Required:         true,
+										Optional:         true,
+										Computed:         true,
 										ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
 									},
 									"ca_cert": {
-										Description:      "",
+										Description:      "PEM or base64-encoded PEM certificates of the certificate authority of the Kubernetes API server. Read from `from_kubeconfig` when omitted.",
 										Type:             schema.TypeString,
-										Required:         true,
-										ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+										Optional:         true,
+										Computed:         true,
+										ValidateFunc:     validateCaCert,
+										DiffSuppressFunc: suppressEquivalentCaCert,
 									},
+									"ca_cert_info": caCertInfoSchema(),
 								},
 							},
 						},
@@ -67,11 +114,13 @@ func resourceRedfoxClusterStatus() *schema.Resource {
 							ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
 						},
 						"aws_iam_idps": {
-							Description: "",
-							Type:        schema.TypeMap,
-							Optional:    true,
-							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description:  "ARNs of the AWS IAM OIDC identity providers trusting `service_account_issuer`, such as `arn:aws:iam::123456789012:oidc-provider/oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE`.",
+							Type:         schema.TypeMap,
+							Optional:     true,
+							Elem:         &schema.Schema{Type: schema.TypeString},
+							ValidateFunc: validateAwsIamIdps,
 						},
+						"aws_iam_idp_entries": awsIamIdpEntriesSchema(),
 					},
 				},
 			},
@@ -90,7 +139,34 @@ func resourceRedfoxClusterStatusApply(ctx context.Context, d *schema.ResourceDat
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	apiserver, err := resolveApiserver(d.GetRawConfig(), status.Apiserver, d.Get("from_kubeconfig").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	status.Apiserver = *apiserver
 
+	if d.Get("verify_issuer").(bool) {
+		diags := verifyOidcIssuer(ctx, oidcHTTPClient(meta), status.ServiceAccountIssuer)
+		if diags.HasError() {
+			return diags
+		}
+	}
+	warnings := verifyEndpoint(ctx, d.Get("verify_endpoint").([]interface{}), status.Apiserver.Endpoint, status.Apiserver.CaCert, meta)
+	if warnings.HasError() {
+		return warnings
+	}
+
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	if d.IsNewResource() {
+		timeout = d.Timeout(schema.TimeoutCreate)
+	}
+	parent, diags := waitForParentCluster(ctx, d, meta, metadata.Namespace, metadata.Name, timeout)
+	if diags.HasError() {
+		return diags
+	}
+
+	// The UID turns the patch into a precondition, status is never written to another Cluster
+	metadata.UID = parent.UID
 	cluster := &redfoxV1alpha1.Cluster{
 		TypeMeta:   clusterTypeMeta,
 		ObjectMeta: metadata,
@@ -99,31 +175,89 @@ func resourceRedfoxClusterStatusApply(ctx context.Context, d *schema.ResourceDat
 
 	tflog.Info(ctx, fmt.Sprintf("Apply %s: %#v", clusterKind.Kind, cluster))
 
-	buf, err := json.Marshal(cluster)
+	// Spec is owned by redfox_cluster and must never be written from here
+	buf, err := marshalApplyPatch(cluster, "spec")
 	if err != nil {
 		tflog.Debug(ctx, fmt.Sprintf("%s Marshal error: %#v", clusterKind.Kind, err))
 		return diag.FromErr(err)
 	}
-	out, err := conn.MetadataV1alpha1().Clusters(cluster.Namespace).Patch(ctx, cluster.Name, types.ApplyPatchType, buf, metav1.PatchOptions{FieldManager: defaultFieldManagerName}, "status")
+	out, err := patchClusterStatusWithRetry(ctx, conn, cluster, buf, clusterStatusWriteBackoff)
 	if err != nil {
-		return diag.Errorf(fmt.Sprintf("Failed to create %s: %#v", clusterKind.Kind, err))
+		diags := kubeErrorDiagnostics(err, "apply the status of", clusterKind.Kind, resourceRedfoxClusterStatus().Schema)
+		if isFieldManagerConflict(err) {
+			diags = append(diags, clusterStatusConflictDiagnostic(ctx, conn, cluster.Namespace, cluster.Name))
+		}
+		return diags
 	}
 
 	d.SetId(buildId(out.ObjectMeta))
 
 	tflog.Info(ctx, fmt.Sprintf("Submitted new %s: %#v", clusterKind.Kind, out))
 
-	return resourceRedfoxClusterStatusRead(ctx, d, meta)
+	return append(warnings, resourceRedfoxClusterStatusRead(ctx, d, meta)...)
+}
+
+// waitForParentCluster waits until the Cluster whose status is managed exists, and verifies that it is
+// still the object recorded in state.
+func waitForParentCluster(ctx context.Context, d *schema.ResourceData, meta interface{}, namespace, name string, timeout time.Duration) (*redfoxV1alpha1.Cluster, diag.Diagnostics) {
+	conn, err := meta.(KubeClientsets).RedfoxClient()
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	var parent *redfoxV1alpha1.Cluster
+	err = resource.RetryContext(ctx, timeout, func() *resource.RetryError {
+		out, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if statusErr, ok := err.(*errors.StatusError); ok && errors.IsNotFound(statusErr) {
+				tflog.Info(ctx, fmt.Sprintf("Waiting for parent %s %s/%s to be created", clusterKind.Kind, namespace, name))
+				return resource.RetryableError(err)
+			}
+			return resource.NonRetryableError(err)
+		}
+		parent = out
+		return nil
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Parent %s %s/%s does not exist", clusterKind.Kind, namespace, name),
+				Detail:   fmt.Sprintf("The %s was still missing after %s. Create it with a `redfox_cluster` resource, or increase the timeouts of this resource.", clusterKind.Kind, timeout),
+			}}
+		}
+		return nil, kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
+	}
+
+	if recorded, _ := d.Get("metadata.0.uid").(string); !d.IsNewResource() && recorded != "" && recorded != string(parent.UID) {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Parent %s %s/%s was recreated", clusterKind.Kind, namespace, name),
+			Detail:   fmt.Sprintf("The UID recorded in state is %q but the %s now has UID %q. Refresh to plan writing the status to the new object.", recorded, clusterKind.Kind, parent.UID),
+		}}
+	}
+	return parent, nil
 }
 
 func resourceRedfoxClusterStatusRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return readRedfoxClusterStatus(ctx, d, meta, false)
+}
+
+// readRedfoxClusterStatus reads the status of the Cluster of d into d. The redfox_cluster data source
+// reuses it with dataSource set, to skip the attributes only the resource has.
+func readRedfoxClusterStatus(ctx context.Context, d *schema.ResourceData, meta interface{}, dataSource bool) diag.Diagnostics {
 	exists, err := resourceRedfoxClusterExists(ctx, d, meta)
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
 	}
 	if !exists {
+		id := d.Id()
 		d.SetId("")
-		return diag.Diagnostics{}
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Parent %s of the status was deleted", clusterKind.Kind),
+			Detail:   fmt.Sprintf("%s %q no longer exists, the status written by Terraform was lost with it and will be written again once the %s is recreated.", clusterKind.Kind, id, clusterKind.Kind),
+		}}
 	}
 
 	conn, err := meta.(KubeClientsets).RedfoxClient()
@@ -140,15 +274,29 @@ func resourceRedfoxClusterStatusRead(ctx context.Context, d *schema.ResourceData
 	cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		tflog.Debug(ctx, fmt.Sprintf("Received error: %#v", err))
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
 	}
 	tflog.Info(ctx, fmt.Sprintf("Received %s: %#v", clusterKind.Kind, cluster))
 
+	if recorded, _ := d.Get("metadata.0.uid").(string); !dataSource && recorded != "" && recorded != string(cluster.UID) {
+		d.SetId("")
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Parent %s of the status was recreated, planning to write the status again", clusterKind.Kind),
+			Detail:   fmt.Sprintf("%s %q was recreated outside of Terraform: the UID recorded in state is %q but the API server returned %q. The status written by Terraform was lost with the previous object.", clusterKind.Kind, buildId(cluster.ObjectMeta), recorded, cluster.UID),
+		}}
+	}
+
 	err = d.Set("metadata", flattenMetadata(cluster.ObjectMeta, d, meta))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	err = setObjectVersion(d, cluster.ObjectMeta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	status, err := flattenClusterStatus(cluster.Status, d, meta)
 	if err != nil {
 		return diag.FromErr(err)
@@ -158,7 +306,14 @@ func resourceRedfoxClusterStatusRead(ctx context.Context, d *schema.ResourceData
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	return nil
+
+	if !dataSource {
+		err = d.Set("apiserver", flattenApiserver(cluster.Status.Apiserver))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return caCertExpiryDiagnostics(buildId(cluster.ObjectMeta), cluster.Status.Apiserver.CaCert, meta)
 }
 
 func resourceRedfoxClusterStatusDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -174,7 +329,7 @@ func resourceRedfoxClusterStatusDelete(ctx context.Context, d *schema.ResourceDa
 
 	exists, err := resourceRedfoxClusterExists(ctx, d, meta)
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "read", clusterKind.Kind, nil)
 	}
 	if !exists {
 		d.SetId("")
@@ -190,7 +345,7 @@ func resourceRedfoxClusterStatusDelete(ctx context.Context, d *schema.ResourceDa
 	}
 	_, err = conn.MetadataV1alpha1().Clusters(namespace).Patch(ctx, name, types.JSONPatchType, buf, metav1.PatchOptions{FieldManager: defaultFieldManagerName}, "status")
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "delete the status of", clusterKind.Kind, nil)
 	}
 
 	tflog.Info(ctx, fmt.Sprintf("%s %s deleted", clusterKind.Kind, name))
@@ -198,3 +353,118 @@ func resourceRedfoxClusterStatusDelete(ctx context.Context, d *schema.ResourceDa
 	d.SetId("")
 	return nil
 }
+
+func resourceRedfoxClusterStatusImportLookup() importLookup {
+	return importLookup{
+		kind: clusterKind,
+		get: func(ctx context.Context, meta interface{}, namespace, name string) (*metav1.ObjectMeta, error) {
+			conn, err := meta.(KubeClientsets).RedfoxClient()
+			if err != nil {
+				return nil, err
+			}
+			cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if cluster.Status.Apiserver.Endpoint == "" && cluster.Status.ServiceAccountIssuer == "" {
+				return nil, fmt.Errorf("Cannot import: %s %q has no status populated.", clusterKind.Kind, buildId(cluster.ObjectMeta))
+			}
+			return &cluster.ObjectMeta, nil
+		},
+		list:              listClusterMetadata,
+		listByClusterName: listClusterMetadataByClusterName,
+	}
+}
+
+// clusterStatusWriteBackoff bounds the retries of status writes failing with conflicts or transient errors.
+var clusterStatusWriteBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 200 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.5,
+	Cap:      5 * time.Second,
+}
+
+// isRetriableStatusWriteError reports whether a status write failed because of a concurrent write of the
+// Cluster, or a transient condition of the API server. Conflicts of server-side apply between field
+// managers are not retried: another field manager owns some of the fields, and applying the same
+// fields again cannot succeed.
+func isRetriableStatusWriteError(err error) bool {
+	return (errors.IsConflict(err) && !isFieldManagerConflict(err)) ||
+		errors.IsServerTimeout(err) ||
+		errors.IsTimeout(err) ||
+		errors.IsTooManyRequests(err) ||
+		errors.IsInternalError(err) ||
+		errors.IsServiceUnavailable(err)
+}
+
+// isFieldManagerConflict reports whether err is a conflict of server-side apply with another field manager,
+// rather than a write racing another write of the object.
+func isFieldManagerConflict(err error) bool {
+	return errors.IsConflict(err) && errors.HasStatusCause(err, metav1.CauseTypeFieldManagerConflict)
+}
+
+// patchClusterStatusWithRetry applies buf to the status of cluster, retrying the errors accepted by
+// isRetriableStatusWriteError with backoff. The Cluster is read again before every retry, to stop
+// when it was recreated meanwhile.
+func patchClusterStatusWithRetry(ctx context.Context, conn redfoxClient.Interface, cluster *redfoxV1alpha1.Cluster, buf []byte, backoff wait.Backoff) (*redfoxV1alpha1.Cluster, error) {
+	clusters := conn.MetadataV1alpha1().Clusters(cluster.Namespace)
+
+	var out *redfoxV1alpha1.Cluster
+	attempt := 0
+	err := retry.OnError(backoff, isRetriableStatusWriteError, func() error {
+		attempt++
+		if attempt > 1 {
+			current, err := clusters.Get(ctx, cluster.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if cluster.UID != "" && current.UID != cluster.UID {
+				return fmt.Errorf("%s %s/%s was recreated while writing its status: UID changed from %q to %q", clusterKind.Kind, cluster.Namespace, cluster.Name, cluster.UID, current.UID)
+			}
+			tflog.Info(ctx, fmt.Sprintf("Retrying status write of %s %s/%s (attempt %d) at resourceVersion %s", clusterKind.Kind, cluster.Namespace, cluster.Name, attempt, current.ResourceVersion))
+		}
+
+		var err error
+		out, err = clusters.Patch(ctx, cluster.Name, types.ApplyPatchType, buf, metav1.PatchOptions{FieldManager: defaultFieldManagerName}, "status")
+		if err != nil {
+			tflog.Debug(ctx, fmt.Sprintf("Status write of %s %s/%s failed: %#v", clusterKind.Kind, cluster.Namespace, cluster.Name, err))
+		}
+		return err
+	})
+	return out, err
+}
+
+// clusterStatusConflictDiagnostic describes the other field managers of the status of a Cluster, to
+// tell who is writing the status concurrently when applying it conflicts.
+func clusterStatusConflictDiagnostic(ctx context.Context, conn redfoxClient.Interface, namespace, name string) diag.Diagnostic {
+	d := diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Status of %s %s/%s is written concurrently by another field manager", clusterKind.Kind, namespace, name),
+	}
+
+	cluster, err := conn.MetadataV1alpha1().Clusters(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		d.Detail = fmt.Sprintf("The conflicting field manager could not be determined: %s", err)
+		return d
+	}
+
+	var managers []string
+	for _, entry := range cluster.ManagedFields {
+		if entry.Subresource != "status" || entry.Manager == defaultFieldManagerName {
+			continue
+		}
+		manager := fmt.Sprintf("%s (%s", entry.Manager, entry.Operation)
+		if entry.Time != nil {
+			manager += fmt.Sprintf(", last write at %s", entry.Time.UTC().Format(time.RFC3339))
+		}
+		managers = append(managers, manager+")")
+	}
+	if len(managers) == 0 {
+		d.Detail = "No other field manager currently owns fields of the status, the conflicting write may have been removed since."
+		return d
+	}
+	sort.Strings(managers)
+	d.Detail = fmt.Sprintf("Other field managers of the status: %s. Remove the fields they own from this resource, or stop them from writing the status.", strings.Join(managers, ", "))
+	return d
+}
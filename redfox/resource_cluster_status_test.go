@@ -0,0 +1,179 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	"github.com/krafton-hq/redfox/pkg/generated/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testClusterStatusWriteBackoff = wait.Backoff{Steps: 4, Duration: time.Millisecond}
+
+func testStatusCluster(uid types.UID) *redfoxV1alpha1.Cluster {
+	return &redfoxV1alpha1.Cluster{
+		TypeMeta:   clusterTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Namespace: "redfox-metadata", Name: "test", UID: uid, ResourceVersion: "100"},
+	}
+}
+
+// testResourceVersionConflict is the conflict of a write racing another write of the Cluster.
+func testResourceVersionConflict() error {
+	return errors.NewConflict(schema.GroupResource{Group: clusterKind.Group, Resource: "clusters"}, "test", fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+}
+
+// testFieldManagerConflict is the conflict of server-side apply with another field manager.
+func testFieldManagerConflict() error {
+	err := errors.NewConflict(schema.GroupResource{Group: clusterKind.Group, Resource: "clusters"}, "test", nil)
+	err.ErrStatus.Details.Causes = []metav1.StatusCause{{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "redfox-controller"`,
+		Field:   ".status.apiserver.endpoint",
+	}}
+	return err
+}
+
+// testClusterStatusClient returns a fake clientset whose status patches fail with the errors of
+// patchErrors, in order, before succeeding, and whose reads return the Cluster with getUids, in order.
+func testClusterStatusClient(patchErrors []error, getUids []types.UID) (*fake.Clientset, *int, *int) {
+	client := fake.NewSimpleClientset()
+	patches, gets := 0, 0
+	client.PrependReactor("patch", "clusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patches++
+		if action.GetSubresource() != "status" {
+			return true, nil, errors.NewBadRequest("expected a patch of the status subresource")
+		}
+		if patches <= len(patchErrors) {
+			return true, nil, patchErrors[patches-1]
+		}
+		return true, testStatusCluster("uid-1"), nil
+	})
+	client.PrependReactor("get", "clusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gets++
+		uid := types.UID("uid-1")
+		if gets <= len(getUids) {
+			uid = getUids[gets-1]
+		}
+		cluster := testStatusCluster(uid)
+		cluster.ManagedFields = []metav1.ManagedFieldsEntry{
+			{Manager: defaultFieldManagerName, Operation: metav1.ManagedFieldsOperationApply, Subresource: "status"},
+			{Manager: "redfox-controller", Operation: metav1.ManagedFieldsOperationUpdate, Subresource: "status"},
+		}
+		return true, cluster, nil
+	})
+	return client, &patches, &gets
+}
+
+func TestPatchClusterStatusWithRetry(t *testing.T) {
+	unavailable := errors.NewServiceUnavailable("etcd leader changed")
+	cases := []struct {
+		name            string
+		patchErrors     []error
+		getUids         []types.UID
+		expectedError   func(error) bool
+		expectedPatches int
+		expectedGets    int
+	}{
+		{
+			name:            "succeeds at once",
+			expectedPatches: 1,
+		},
+		{
+			name:            "retries transient errors",
+			patchErrors:     []error{unavailable, errors.NewTooManyRequests("slow down", 0), errors.NewInternalError(fmt.Errorf("storage is unavailable"))},
+			expectedPatches: 4,
+			expectedGets:    3,
+		},
+		{
+			name:            "gives up after the backoff steps",
+			patchErrors:     []error{unavailable, unavailable, unavailable, unavailable, unavailable},
+			expectedError:   errors.IsServiceUnavailable,
+			expectedPatches: 4,
+			expectedGets:    3,
+		},
+		{
+			name:            "retries conflicts with other writes",
+			patchErrors:     []error{testResourceVersionConflict(), testResourceVersionConflict()},
+			expectedPatches: 3,
+			expectedGets:    2,
+		},
+		{
+			name:            "gives up on conflicts after the backoff steps",
+			patchErrors:     []error{testResourceVersionConflict(), testResourceVersionConflict(), testResourceVersionConflict(), testResourceVersionConflict()},
+			expectedError:   errors.IsConflict,
+			expectedPatches: 4,
+			expectedGets:    3,
+		},
+		{
+			name:            "does not retry field manager conflicts",
+			patchErrors:     []error{testFieldManagerConflict()},
+			expectedError:   isFieldManagerConflict,
+			expectedPatches: 1,
+		},
+		{
+			name:            "stops on a field manager conflict after a conflict with another write",
+			patchErrors:     []error{testResourceVersionConflict(), testFieldManagerConflict()},
+			expectedError:   isFieldManagerConflict,
+			expectedPatches: 2,
+			expectedGets:    1,
+		},
+		{
+			name:            "does not retry other errors",
+			patchErrors:     []error{errors.NewForbidden(schema.GroupResource{Resource: "clusters"}, "test", nil)},
+			expectedError:   errors.IsForbidden,
+			expectedPatches: 1,
+		},
+		{
+			name:        "stops when the parent is recreated",
+			patchErrors: []error{unavailable},
+			getUids:     []types.UID{"uid-2"},
+			expectedError: func(err error) bool {
+				return strings.Contains(err.Error(), "was recreated while writing its status")
+			},
+			expectedPatches: 1,
+			expectedGets:    1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, patches, gets := testClusterStatusClient(tc.patchErrors, tc.getUids)
+			out, err := patchClusterStatusWithRetry(context.Background(), client, testStatusCluster("uid-1"), []byte("{}"), testClusterStatusWriteBackoff)
+			switch {
+			case tc.expectedError == nil && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case tc.expectedError == nil && out.UID != "uid-1":
+				t.Fatalf("expected the patched Cluster, got %#v", out)
+			case tc.expectedError != nil && (err == nil || !tc.expectedError(err)):
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *patches != tc.expectedPatches {
+				t.Errorf("expected %d patches, got %d", tc.expectedPatches, *patches)
+			}
+			if *gets != tc.expectedGets {
+				t.Errorf("expected %d reads, got %d", tc.expectedGets, *gets)
+			}
+		})
+	}
+}
+
+func TestClusterStatusConflictDiagnostic(t *testing.T) {
+	client, _, _ := testClusterStatusClient(nil, nil)
+	d := clusterStatusConflictDiagnostic(context.Background(), client, "redfox-metadata", "test")
+	if d.Severity != diag.Error {
+		t.Fatalf("expected an error, got %#v", d)
+	}
+	if !strings.Contains(d.Detail, "redfox-controller (Update)") || strings.Contains(d.Detail, defaultFieldManagerName) {
+		t.Fatalf("expected only the other field manager in %q", d.Detail)
+	}
+}
@@ -8,10 +8,12 @@
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -25,13 +27,35 @@ func resourceRedfoxNatIp() *schema.Resource {
 		UpdateContext: resourceRedfoxNatIpApply,
 		DeleteContext: resourceRedfoxNatIpDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceRedfoxNatIpImportLookup().StateContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Default: schema.DefaultTimeout(30 * time.Second),
 		},
+		SchemaVersion: 2,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceRedfoxNatIpV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxNatIpStateUpgradeV0,
+			},
+			{
+				Version: 1,
+				Type:    resourceRedfoxNatIpV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRedfoxNatIpStateUpgradeV1,
+			},
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffNatIpCidrs,
+			customizeDiffNatIpType,
+			customizeDiffObjectVersion("spec"),
+		),
 		Schema: map[string]*schema.Schema{
-			"metadata": namespacedMetadataSchema("natip", true),
+			"resource_version": resourceVersionSchema("natip"),
+			"generation":       generationSchema("natip"),
+			"metadata":         namespacedMetadataSchema("natip", true),
+			"on_uid_change":    onUidChangeSchema("natip"),
+			"ip_type":          natIpTypeSchema(),
 			"spec": {
 				Type:        schema.TypeList,
 				Description: "Spec defines the specification of the desired behavior of the deployment. More info: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.9/#deployment-v1-apps",
@@ -41,20 +65,13 @@ func resourceRedfoxNatIp() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"ip_type": {
-							Description:  "IP Type, Can be either IPv4 or IPv6",
+							Description:  "IP Type, Can be either IPv4 or IPv6. Inferred from `cidrs` when omitted, which must then all be of the same type.",
 							Type:         schema.TypeString,
 							Optional:     true,
+							Computed:     true,
 							ValidateFunc: validation.StringInSlice([]string{string(redfoxV1alpha1.Ipv4), string(redfoxV1alpha1.Ipv6)}, false),
 						},
-						"cidrs": {
-							Description: "Classless Inter-Domain Routing notated IP List, Must be end '/<bits>'",
-							Type:        schema.TypeList,
-							Required:    true,
-							Elem: &schema.Schema{
-								Type:             schema.TypeString,
-								ValidateDiagFunc: validation.ToDiagFunc(validation.IsCIDR),
-							},
-						},
+						"cidrs": natIpCidrsSchema(),
 					},
 				},
 			},
@@ -75,7 +92,7 @@ func resourceRedfoxNatIpApply(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	metadata := expandMetadata(d.Get("metadata").([]interface{}))
-	spec, err := expandNatIpSpec(d.Get("spec").([]interface{}))
+	spec, err := expandNatIpSpec(clearInferredNatIpType(d, d.Get("spec").([]interface{})))
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -95,7 +112,7 @@ func resourceRedfoxNatIpApply(ctx context.Context, d *schema.ResourceData, meta
 	}
 	out, err := conn.MetadataV1alpha1().NatIps(natIp.Namespace).Patch(ctx, natIp.Name, types.ApplyPatchType, buf, metav1.PatchOptions{FieldManager: defaultFieldManagerName})
 	if err != nil {
-		return diag.Errorf("Failed to create NatIp: %s", err)
+		return kubeErrorDiagnostics(err, "apply", natipKind.Kind, resourceRedfoxNatIp().Schema)
 	}
 
 	d.SetId(buildId(out.ObjectMeta))
@@ -106,9 +123,15 @@ func resourceRedfoxNatIpApply(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourceRedfoxNatIpRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return readRedfoxNatIp(ctx, d, meta, false)
+}
+
+// readRedfoxNatIp reads the NatIp of d into d. The redfox_natip data source reuses it with dataSource set, to
+// skip the attributes only the resource has.
+func readRedfoxNatIp(ctx context.Context, d *schema.ResourceData, meta interface{}, dataSource bool) diag.Diagnostics {
 	exists, err := resourceRedfoxNatIpExists(ctx, d, meta)
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "read", natipKind.Kind, nil)
 	}
 	if !exists {
 		d.SetId("")
@@ -129,25 +152,50 @@ func resourceRedfoxNatIpRead(ctx context.Context, d *schema.ResourceData, meta i
 	natIp, err := conn.MetadataV1alpha1().NatIps(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		tflog.Debug(ctx, fmt.Sprintf("Received error: %#v", err))
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "read", natipKind.Kind, nil)
 	}
 	tflog.Info(ctx, fmt.Sprintf("Received NatIp: %#v", natIp))
 
+	var diags diag.Diagnostics
+	if !dataSource {
+		var recreated bool
+		recreated, diags = checkUidChange(d, natipKind.Kind, natIp.ObjectMeta)
+		if recreated {
+			d.SetId("")
+			return diags
+		}
+	}
+
 	err = d.Set("metadata", flattenMetadata(natIp.ObjectMeta, d, meta))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	err = setObjectVersion(d, natIp.ObjectMeta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	spec, err := flattenNatIpSpec(natIp.Spec, d, meta)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	// The data source has no top-level ip_type but splits the CIDRs by type
+	if !dataSource {
+		err = d.Set("ip_type", string(natIp.Spec.IpType))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	} else {
+		spec = flattenNatIpCidrsByType(spec, natIp.Spec)
+	}
+
 	err = d.Set("spec", spec)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	return nil
+	return diags
 }
 
 func resourceRedfoxNatIpExists(ctx context.Context, d *schema.ResourceData, meta interface{}) (bool, error) {
@@ -187,7 +235,7 @@ func resourceRedfoxNatIpDelete(ctx context.Context, d *schema.ResourceData, meta
 
 	err = conn.MetadataV1alpha1().NatIps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "delete", natipKind.Kind, nil)
 	}
 
 	err = resource.RetryContext(ctx, d.Timeout(schema.TimeoutDelete), func() *resource.RetryError {
@@ -203,7 +251,7 @@ func resourceRedfoxNatIpDelete(ctx context.Context, d *schema.ResourceData, meta
 		return resource.RetryableError(e)
 	})
 	if err != nil {
-		return diag.FromErr(err)
+		return kubeErrorDiagnostics(err, "delete", natipKind.Kind, nil)
 	}
 
 	tflog.Info(ctx, fmt.Sprintf("Deployment %s deleted", name))
@@ -211,3 +259,33 @@ func resourceRedfoxNatIpDelete(ctx context.Context, d *schema.ResourceData, meta
 	d.SetId("")
 	return nil
 }
+
+func resourceRedfoxNatIpImportLookup() importLookup {
+	return importLookup{
+		kind: natipKind,
+		get: func(ctx context.Context, meta interface{}, namespace, name string) (*metav1.ObjectMeta, error) {
+			conn, err := meta.(KubeClientsets).RedfoxClient()
+			if err != nil {
+				return nil, err
+			}
+			natIp, err := conn.MetadataV1alpha1().NatIps(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return &natIp.ObjectMeta, nil
+		},
+		list: func(ctx context.Context, meta interface{}, namespace string) ([]metav1.ObjectMeta, error) {
+			conn, err := meta.(KubeClientsets).RedfoxClient()
+			if err != nil {
+				return nil, err
+			}
+			natIps, err := conn.MetadataV1alpha1().NatIps(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return lo.Map[redfoxV1alpha1.NatIp, metav1.ObjectMeta](natIps.Items, func(x redfoxV1alpha1.NatIp, _ int) metav1.ObjectMeta {
+				return x.ObjectMeta
+			}), nil
+		},
+	}
+}
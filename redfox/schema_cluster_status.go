@@ -0,0 +1,51 @@
+package redfox
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// clusterStatusComputedSchema is the read-only view of a Cluster's status shared by the redfox_cluster
+// resource and data sources. Only redfox_cluster_status writes the status.
+func clusterStatusComputedSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Status of the cluster as observed on the API server. It is written by `redfox_cluster_status` or the redfox controller and is read-only here.",
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"apiserver": {
+					Type:        schema.TypeList,
+					Description: "Kubernetes API server of the cluster.",
+					Computed:    true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"endpoint": {
+								Type:        schema.TypeString,
+								Description: "URL of the Kubernetes API server.",
+								Computed:    true,
+							},
+							"ca_cert": {
+								Type:        schema.TypeString,
+								Description: "Certificate authority data of the Kubernetes API server.",
+								Computed:    true,
+							},
+							"ca_cert_info": caCertInfoSchema(),
+						},
+					},
+				},
+				"service_account_issuer": {
+					Type:        schema.TypeString,
+					Description: "Issuer URL of the cluster's service account tokens.",
+					Computed:    true,
+				},
+				"aws_iam_idps": {
+					Type:        schema.TypeMap,
+					Description: "AWS IAM OIDC identity provider ARNs trusting the service account issuer.",
+					Computed:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+				"aws_iam_idp_entries": awsIamIdpEntriesSchema(),
+			},
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package redfox
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// testUnknown stands for a value unknown during plan in the configurations given to testResourceDiff.
+const testUnknown = "(known after apply)"
+
+// testResourceDiff plans r with customizeDiff as its only CustomizeDiff, from state and the
+// configuration config, given as it would be in JSON.
+func testResourceDiff(t *testing.T, r *schema.Resource, customizeDiff schema.CustomizeDiffFunc, state *terraform.InstanceState, config map[string]interface{}, meta interface{}) (*terraform.InstanceDiff, error) {
+	t.Helper()
+	raw := testRawConfig(t, r, config)
+	if state == nil {
+		state = &terraform.InstanceState{}
+	}
+	state.RawConfig = raw
+	r.CustomizeDiff = customizeDiff
+	return r.SimpleDiff(context.Background(), state, terraform.NewResourceConfigShimmed(raw, r.CoreConfigSchema()), meta)
+}
+
+// testResourceConfig returns the configuration config of r, given as it would be in JSON, to validate it.
+func testResourceConfig(t *testing.T, r *schema.Resource, config map[string]interface{}) *terraform.ResourceConfig {
+	t.Helper()
+	return terraform.NewResourceConfigShimmed(testRawConfig(t, r, config), r.CoreConfigSchema())
+}
+
+// testRawConfig decodes the configuration config of r, given as it would be in JSON. Strings equal to
+// testUnknown become unknown values.
+func testRawConfig(t *testing.T, r *schema.Resource, config map[string]interface{}) cty.Value {
+	t.Helper()
+	buf, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ctyjson.Unmarshal(buf, r.CoreConfigSchema().ImpliedType())
+	if err != nil {
+		t.Fatalf("invalid configuration: %s", err)
+	}
+	raw, err = cty.Transform(raw, func(path cty.Path, v cty.Value) (cty.Value, error) {
+		if v.Type() == cty.String && v.IsKnown() && !v.IsNull() && v.AsString() == testUnknown {
+			return cty.UnknownVal(cty.String), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
@@ -92,7 +92,7 @@ func namespacedMetadataSchemaIsTemplate(objectName string, generatableName, isTe
 		Description: fmt.Sprintf("Namespace defines the space within which name of the %s must be unique.", objectName),
 		Optional:    true,
 		ForceNew:    true,
-		Default:     conditionalDefault(!isTemplate, "default"),
+		Default:     conditionalDefault(!isTemplate, defaultNamespace),
 	}
 	if generatableName {
 		fields["generate_name"] = &schema.Schema{
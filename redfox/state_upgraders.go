@@ -0,0 +1,416 @@
+package redfox
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/samber/lo"
+)
+
+// The resourceRedfox*V<n> functions freeze the shape of a resource at schema version n so that
+// state written by older provider releases can still be decoded. They only carry what is needed
+// to derive the state type and must not change once released, so they never call the live schema
+// helpers.
+
+// namespacedMetadataSchemaV0 freezes namespacedMetadataSchema as of schema version 0.
+func namespacedMetadataSchemaV0(generatableName bool) *schema.Schema {
+	fields := map[string]*schema.Schema{
+		"annotations": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"generation": {Type: schema.TypeInt, Computed: true},
+		"labels": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"name":             {Type: schema.TypeString, Optional: true, Computed: true},
+		"namespace":        {Type: schema.TypeString, Optional: true},
+		"resource_version": {Type: schema.TypeString, Computed: true},
+		"uid":              {Type: schema.TypeString, Computed: true},
+	}
+	if generatableName {
+		fields["generate_name"] = &schema.Schema{Type: schema.TypeString, Optional: true}
+	}
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Required: true,
+		MaxItems: 1,
+		Elem:     &schema.Resource{Schema: fields},
+	}
+}
+
+// clusterStatusComputedSchemaV1 freezes clusterStatusComputedSchema as of version 1 of redfox_cluster.
+func clusterStatusComputedSchemaV1() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"apiserver": {
+					Type:     schema.TypeList,
+					Computed: true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"endpoint": {Type: schema.TypeString, Computed: true},
+							"ca_cert":  {Type: schema.TypeString, Computed: true},
+						},
+					},
+				},
+				"service_account_issuer": {Type: schema.TypeString, Computed: true},
+				"aws_iam_idps": {
+					Type:     schema.TypeMap,
+					Computed: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+func resourceRedfoxClusterV0() *schema.Resource {
+	return &schema.Resource{
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(30 * time.Second),
+		},
+		Schema: map[string]*schema.Schema{
+			"metadata": namespacedMetadataSchemaV0(true),
+			"spec": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cluster_name":     {Type: schema.TypeString, Required: true},
+						"cluster_region":   {Type: schema.TypeString, Required: true},
+						"cluster_group":    {Type: schema.TypeString, Required: true},
+						"service_phase":    {Type: schema.TypeString, Required: true},
+						"service_tag":      {Type: schema.TypeString, Required: true},
+						"cluster_engine":   {Type: schema.TypeString, Required: true},
+						"infra_vendor":     {Type: schema.TypeString, Required: true},
+						"infra_account_id": {Type: schema.TypeString, Required: true},
+						"roles": {
+							Type:     schema.TypeList,
+							Required: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"vpc_id": {Type: schema.TypeString, Required: true},
+						"database_subnet_ids": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourceRedfoxClusterStateUpgradeV0 adds `on_uid_change`, which did not exist in version 0,
+// with its default so that upgraded resources do not show a spurious in-place update.
+func resourceRedfoxClusterStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	return upgradeOnUidChangeStateV0(rawState), nil
+}
+
+func resourceRedfoxClusterV1() *schema.Resource {
+	r := resourceRedfoxClusterV0()
+	r.Timeouts = &schema.ResourceTimeout{
+		Create:  schema.DefaultTimeout(5 * time.Minute),
+		Update:  schema.DefaultTimeout(5 * time.Minute),
+		Default: schema.DefaultTimeout(30 * time.Second),
+	}
+	r.Schema["resource_version"] = &schema.Schema{Type: schema.TypeString, Computed: true}
+	r.Schema["generation"] = &schema.Schema{Type: schema.TypeInt, Computed: true}
+	r.Schema["on_uid_change"] = &schema.Schema{Type: schema.TypeString, Optional: true}
+	r.Schema["wait_for"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"fields": {
+					Type:     schema.TypeMap,
+					Required: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+	r.Schema["status"] = clusterStatusComputedSchemaV1()
+	return r
+}
+
+// resourceRedfoxClusterStateUpgradeV1 adds `identity_change`, which did not exist in version 1,
+// with its default so that upgraded resources do not show a spurious in-place update.
+func resourceRedfoxClusterStateUpgradeV1(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if rawState == nil {
+		rawState = map[string]interface{}{}
+	}
+	if v, ok := rawState["identity_change"].(string); !ok || v == "" {
+		rawState["identity_change"] = identityChangeError
+	}
+	return rawState, nil
+}
+
+func resourceRedfoxClusterV2() *schema.Resource {
+	r := resourceRedfoxClusterV1()
+	r.Schema["identity_change"] = &schema.Schema{Type: schema.TypeString, Optional: true}
+	return r
+}
+
+// resourceRedfoxClusterStateUpgradeV2 removes duplicated `spec.roles`, which became a set in version 3.
+func resourceRedfoxClusterStateUpgradeV2(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	specs, ok := rawState["spec"].([]interface{})
+	if !ok {
+		return rawState, nil
+	}
+	for _, raw := range specs {
+		spec, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		roles, ok := spec["roles"].([]interface{})
+		if !ok {
+			continue
+		}
+		spec["roles"] = lo.Uniq[string](sliceOfString(roles))
+	}
+	return rawState, nil
+}
+
+func resourceRedfoxNatIpV0() *schema.Resource {
+	return &schema.Resource{
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(30 * time.Second),
+		},
+		Schema: map[string]*schema.Schema{
+			"metadata": namespacedMetadataSchemaV0(true),
+			"spec": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip_type": {Type: schema.TypeString, Optional: true},
+						"cidrs": {
+							Type:     schema.TypeList,
+							Required: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourceRedfoxNatIpStateUpgradeV0 adds `on_uid_change`, which did not exist in version 0.
+func resourceRedfoxNatIpStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	return upgradeOnUidChangeStateV0(rawState), nil
+}
+
+func upgradeOnUidChangeStateV0(rawState map[string]interface{}) map[string]interface{} {
+	if rawState == nil {
+		rawState = map[string]interface{}{}
+	}
+	if v, ok := rawState["on_uid_change"].(string); !ok || v == "" {
+		rawState["on_uid_change"] = uidChangeWarn
+	}
+	return rawState
+}
+
+func resourceRedfoxNatIpV1() *schema.Resource {
+	r := resourceRedfoxNatIpV0()
+	r.Schema["resource_version"] = &schema.Schema{Type: schema.TypeString, Computed: true}
+	r.Schema["generation"] = &schema.Schema{Type: schema.TypeInt, Computed: true}
+	r.Schema["on_uid_change"] = &schema.Schema{Type: schema.TypeString, Optional: true}
+	return r
+}
+
+// resourceRedfoxNatIpStateUpgradeV1 canonicalizes and removes duplicated `spec.cidrs`, which became
+// a set of networks in version 2.
+func resourceRedfoxNatIpStateUpgradeV1(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	specs, ok := rawState["spec"].([]interface{})
+	if !ok {
+		return rawState, nil
+	}
+	for _, raw := range specs {
+		spec, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cidrs, ok := spec["cidrs"].([]interface{})
+		if !ok {
+			continue
+		}
+		spec["cidrs"] = canonicalCidrs(sliceOfString(cidrs))
+	}
+	return rawState, nil
+}
+
+func resourceRedfoxClusterStatusV0() *schema.Resource {
+	return &schema.Resource{
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(30 * time.Second),
+		},
+		Schema: map[string]*schema.Schema{
+			"metadata": namespacedMetadataSchemaV0(false),
+			"status": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"apiserver": {
+							Type:     schema.TypeList,
+							Required: true,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"endpoint": {Type: schema.TypeString, Required: true},
+									"ca_cert":  {Type: schema.TypeString, Required: true},
+								},
+							},
+						},
+						"service_account_issuer": {Type: schema.TypeString, Required: true},
+						"aws_iam_idps": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourceRedfoxClusterStatusStateUpgradeV0 fills `resource_version` and `generation`, which did not
+// exist in version 0, from the metadata recorded in state.
+func resourceRedfoxClusterStatusStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if rawState == nil {
+		rawState = map[string]interface{}{}
+	}
+	metadatas, _ := rawState["metadata"].([]interface{})
+	if len(metadatas) == 0 {
+		return rawState, nil
+	}
+	if metadata, ok := metadatas[0].(map[string]interface{}); ok {
+		rawState["resource_version"] = metadata["resource_version"]
+		rawState["generation"] = metadata["generation"]
+	}
+	return rawState, nil
+}
+
+func resourceRedfoxClusterStatusV1() *schema.Resource {
+	r := resourceRedfoxClusterStatusV0()
+	r.Timeouts = &schema.ResourceTimeout{
+		Create:  schema.DefaultTimeout(5 * time.Minute),
+		Update:  schema.DefaultTimeout(5 * time.Minute),
+		Default: schema.DefaultTimeout(30 * time.Second),
+	}
+	r.Schema["resource_version"] = &schema.Schema{Type: schema.TypeString, Computed: true}
+	r.Schema["generation"] = &schema.Schema{Type: schema.TypeInt, Computed: true}
+	return r
+}
+
+// resourceRedfoxClusterStatusStateUpgradeV1 fills `status.apiserver.ca_cert_info` and
+// `status.aws_iam_idp_entries`, which did not exist in version 1, from the values they are parsed from.
+func resourceRedfoxClusterStatusStateUpgradeV1(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	statuses, ok := rawState["status"].([]interface{})
+	if !ok {
+		return rawState, nil
+	}
+	for _, raw := range statuses {
+		status, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		apiservers, _ := status["apiserver"].([]interface{})
+		for _, raw := range apiservers {
+			apiserver, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			apiserver["ca_cert_info"] = []any{}
+			if caCert, ok := apiserver["ca_cert"].(string); ok {
+				if certs, err := decodeCaCert(caCert); err == nil {
+					apiserver["ca_cert_info"] = flattenCaCertInfo(certs)
+				}
+			}
+		}
+		idps, _ := status["aws_iam_idps"].(map[string]interface{})
+		status["aws_iam_idp_entries"] = flattenAwsIamIdpEntries(expandStringMap(idps))
+	}
+	return rawState, nil
+}
+
+func resourceRedfoxClusterStatusV2() *schema.Resource {
+	r := resourceRedfoxClusterStatusV1()
+	r.Schema["verify_issuer"] = &schema.Schema{Type: schema.TypeBool, Optional: true}
+	r.Schema["verify_endpoint"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"severity": {Type: schema.TypeString, Optional: true},
+				"timeout":  {Type: schema.TypeString, Optional: true},
+			},
+		},
+	}
+	status := r.Schema["status"].Elem.(*schema.Resource)
+	status.Schema["apiserver"].Elem.(*schema.Resource).Schema["ca_cert_info"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"subject":            {Type: schema.TypeString, Computed: true},
+				"issuer":             {Type: schema.TypeString, Computed: true},
+				"not_before":         {Type: schema.TypeString, Computed: true},
+				"not_after":          {Type: schema.TypeString, Computed: true},
+				"sha256_fingerprint": {Type: schema.TypeString, Computed: true},
+			},
+		},
+	}
+	status.Schema["aws_iam_idp_entries"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key":           {Type: schema.TypeString, Computed: true},
+				"partition":     {Type: schema.TypeString, Computed: true},
+				"account_id":    {Type: schema.TypeString, Computed: true},
+				"provider_host": {Type: schema.TypeString, Computed: true},
+			},
+		},
+	}
+	return r
+}
+
+// resourceRedfoxClusterStatusStateUpgradeV2 fills the top-level `apiserver`, which did not exist in
+// version 2, from `status.apiserver`. Every attribute of it was explicit before `from_kubeconfig`.
+func resourceRedfoxClusterStatusStateUpgradeV2(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	statuses, _ := rawState["status"].([]interface{})
+	if len(statuses) == 0 {
+		return rawState, nil
+	}
+	status, _ := statuses[0].(map[string]interface{})
+	apiservers, _ := status["apiserver"].([]interface{})
+	if len(apiservers) == 0 {
+		return rawState, nil
+	}
+	if apiserver, ok := apiservers[0].(map[string]interface{}); ok {
+		rawState["apiserver"] = []interface{}{map[string]interface{}{
+			"endpoint": apiserver["endpoint"],
+			"ca_cert":  apiserver["ca_cert"],
+		}}
+	}
+	return rawState, nil
+}
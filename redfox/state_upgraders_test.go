@@ -0,0 +1,384 @@
+package redfox
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type stateUpgradeTestCase struct {
+	name     string
+	rawState map[string]interface{}
+	expected map[string]interface{}
+}
+
+func runStateUpgradeTests(t *testing.T, upgrade schema.StateUpgradeFunc, cases []stateUpgradeTestCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := upgrade(context.Background(), tc.rawState, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("expected:\n%#v\ngot:\n%#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// upgradeStateToCurrent runs every upgrader of r from version on rawState and checks that the result
+// decodes with the current schema of r.
+func upgradeStateToCurrent(t *testing.T, r *schema.Resource, version int, rawState map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	for _, upgrader := range r.StateUpgraders {
+		if upgrader.Version < version {
+			continue
+		}
+		// The state of each version must decode with the type frozen for it
+		buf, err := json.Marshal(rawState)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ctyjson.Unmarshal(buf, upgrader.Type); err != nil {
+			t.Fatalf("state of version %d does not match its frozen type: %s", upgrader.Version, err)
+		}
+		rawState, err = upgrader.Upgrade(context.Background(), rawState, nil)
+		if err != nil {
+			t.Fatalf("upgrading from version %d: %s", upgrader.Version, err)
+		}
+	}
+	buf, err := json.Marshal(rawState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctyjson.Unmarshal(buf, r.CoreConfigSchema().ImpliedType()); err != nil {
+		t.Fatalf("upgraded state does not match version %d: %s", r.SchemaVersion, err)
+	}
+	return rawState
+}
+
+func testMetadataStateV0() []interface{} {
+	return []interface{}{map[string]interface{}{
+		"name":             "test",
+		"namespace":        "redfox-metadata",
+		"generate_name":    "",
+		"labels":           map[string]interface{}{"app": "test"},
+		"annotations":      map[string]interface{}{},
+		"generation":       1,
+		"resource_version": "100",
+		"uid":              "5c3e8f4d-1f3a-4b9e-9c1e-2f0a7d6b8e01",
+	}}
+}
+
+func testClusterSpecStateV0(roles interface{}) []interface{} {
+	return []interface{}{map[string]interface{}{
+		"cluster_name":        "test-cluster",
+		"cluster_region":      "ap-northeast-2",
+		"cluster_group":       "test",
+		"service_phase":       "dev",
+		"service_tag":         "test",
+		"cluster_engine":      "eks",
+		"infra_vendor":        "aws",
+		"infra_account_id":    "123456789012",
+		"roles":               roles,
+		"vpc_id":              "vpc-0123456789abcdef0",
+		"database_subnet_ids": []interface{}{},
+	}}
+}
+
+func TestResourceRedfoxClusterStateUpgradeV0(t *testing.T) {
+	runStateUpgradeTests(t, resourceRedfoxClusterStateUpgradeV0, []stateUpgradeTestCase{
+		{
+			name:     "adds on_uid_change",
+			rawState: map[string]interface{}{"id": "redfox-metadata/test"},
+			expected: map[string]interface{}{"id": "redfox-metadata/test", "on_uid_change": uidChangeWarn},
+		},
+		{
+			name:     "replaces an empty on_uid_change",
+			rawState: map[string]interface{}{"on_uid_change": ""},
+			expected: map[string]interface{}{"on_uid_change": uidChangeWarn},
+		},
+		{
+			name:     "keeps on_uid_change",
+			rawState: map[string]interface{}{"on_uid_change": uidChangeRecreate},
+			expected: map[string]interface{}{"on_uid_change": uidChangeRecreate},
+		},
+		{
+			name:     "nil state",
+			rawState: nil,
+			expected: map[string]interface{}{"on_uid_change": uidChangeWarn},
+		},
+	})
+}
+
+func TestResourceRedfoxClusterStateUpgradeV1(t *testing.T) {
+	runStateUpgradeTests(t, resourceRedfoxClusterStateUpgradeV1, []stateUpgradeTestCase{
+		{
+			name:     "adds identity_change",
+			rawState: map[string]interface{}{"on_uid_change": uidChangeWarn},
+			expected: map[string]interface{}{"on_uid_change": uidChangeWarn, "identity_change": identityChangeError},
+		},
+		{
+			name:     "keeps identity_change",
+			rawState: map[string]interface{}{"identity_change": identityChangeReplace},
+			expected: map[string]interface{}{"identity_change": identityChangeReplace},
+		},
+		{
+			name:     "nil state",
+			rawState: nil,
+			expected: map[string]interface{}{"identity_change": identityChangeError},
+		},
+	})
+}
+
+func TestResourceRedfoxClusterStateUpgradeV2(t *testing.T) {
+	runStateUpgradeTests(t, resourceRedfoxClusterStateUpgradeV2, []stateUpgradeTestCase{
+		{
+			name:     "removes duplicated roles",
+			rawState: map[string]interface{}{"spec": testClusterSpecStateV0([]interface{}{"default", "ingress", "default"})},
+			expected: map[string]interface{}{"spec": testClusterSpecStateV0([]string{"default", "ingress"})},
+		},
+		{
+			name:     "keeps distinct roles",
+			rawState: map[string]interface{}{"spec": testClusterSpecStateV0([]interface{}{"ingress", "default"})},
+			expected: map[string]interface{}{"spec": testClusterSpecStateV0([]string{"ingress", "default"})},
+		},
+		{
+			name:     "no spec",
+			rawState: map[string]interface{}{"id": "redfox-metadata/test"},
+			expected: map[string]interface{}{"id": "redfox-metadata/test"},
+		},
+	})
+}
+
+func TestResourceRedfoxClusterStateUpgradeFromV0(t *testing.T) {
+	actual := upgradeStateToCurrent(t, resourceRedfoxCluster(), 0, map[string]interface{}{
+		"id":       "redfox-metadata/test",
+		"metadata": testMetadataStateV0(),
+		"spec":     testClusterSpecStateV0([]interface{}{"default", "default"}),
+	})
+	if actual["on_uid_change"] != uidChangeWarn || actual["identity_change"] != identityChangeError {
+		t.Fatalf("expected the defaults of on_uid_change and identity_change, got %#v", actual)
+	}
+	if roles := actual["spec"].([]interface{})[0].(map[string]interface{})["roles"]; !reflect.DeepEqual(roles, []string{"default"}) {
+		t.Fatalf("expected deduplicated roles, got %#v", roles)
+	}
+}
+
+func testNatIpSpecStateV0(ipType string, cidrs interface{}) []interface{} {
+	return []interface{}{map[string]interface{}{
+		"ip_type": ipType,
+		"cidrs":   cidrs,
+	}}
+}
+
+func TestResourceRedfoxNatIpStateUpgradeV0(t *testing.T) {
+	runStateUpgradeTests(t, resourceRedfoxNatIpStateUpgradeV0, []stateUpgradeTestCase{
+		{
+			name:     "adds on_uid_change",
+			rawState: map[string]interface{}{"id": "redfox-metadata/test"},
+			expected: map[string]interface{}{"id": "redfox-metadata/test", "on_uid_change": uidChangeWarn},
+		},
+		{
+			name:     "keeps on_uid_change",
+			rawState: map[string]interface{}{"on_uid_change": uidChangeRecreate},
+			expected: map[string]interface{}{"on_uid_change": uidChangeRecreate},
+		},
+	})
+}
+
+func TestResourceRedfoxNatIpStateUpgradeV1(t *testing.T) {
+	runStateUpgradeTests(t, resourceRedfoxNatIpStateUpgradeV1, []stateUpgradeTestCase{
+		{
+			name:     "canonicalizes and sorts cidrs",
+			rawState: map[string]interface{}{"spec": testNatIpSpecStateV0("Ipv4", []interface{}{"10.0.1.7/24", "10.0.0.0/24"})},
+			expected: map[string]interface{}{"spec": testNatIpSpecStateV0("Ipv4", []string{"10.0.0.0/24", "10.0.1.0/24"})},
+		},
+		{
+			name:     "removes duplicated networks",
+			rawState: map[string]interface{}{"spec": testNatIpSpecStateV0("Ipv6", []interface{}{"2001:DB8::/32", "2001:db8::1/32"})},
+			expected: map[string]interface{}{"spec": testNatIpSpecStateV0("Ipv6", []string{"2001:db8::/32"})},
+		},
+		{
+			name:     "keeps invalid cidrs",
+			rawState: map[string]interface{}{"spec": testNatIpSpecStateV0("", []interface{}{"invalid", "10.0.0.0/8"})},
+			expected: map[string]interface{}{"spec": testNatIpSpecStateV0("", []string{"10.0.0.0/8", "invalid"})},
+		},
+		{
+			name:     "no spec",
+			rawState: map[string]interface{}{"id": "redfox-metadata/test"},
+			expected: map[string]interface{}{"id": "redfox-metadata/test"},
+		},
+	})
+}
+
+func TestResourceRedfoxNatIpStateUpgradeFromV0(t *testing.T) {
+	actual := upgradeStateToCurrent(t, resourceRedfoxNatIp(), 0, map[string]interface{}{
+		"id":       "redfox-metadata/test",
+		"metadata": testMetadataStateV0(),
+		"spec":     testNatIpSpecStateV0("Ipv4", []interface{}{"10.0.0.1/24", "10.0.0.0/24"}),
+	})
+	if actual["on_uid_change"] != uidChangeWarn {
+		t.Fatalf("expected the default of on_uid_change, got %#v", actual)
+	}
+	if cidrs := actual["spec"].([]interface{})[0].(map[string]interface{})["cidrs"]; !reflect.DeepEqual(cidrs, []string{"10.0.0.0/24"}) {
+		t.Fatalf("expected canonical cidrs, got %#v", cidrs)
+	}
+}
+
+// testCaCert returns a new self-signed CA certificate named commonName, its key and its PEM encoding.
+func testCaCert(t *testing.T, commonName string) (*x509.Certificate, *ecdsa.PrivateKey, string) {
+	t.Helper()
+	return testCaCertValidUntil(t, commonName, time.Now().Add(24*time.Hour))
+}
+
+// testCaCertValidUntil returns a new self-signed CA certificate named commonName and valid until
+// notAfter, its key and its PEM encoding.
+func testCaCertValidUntil(t *testing.T, commonName string, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-48 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func testClusterStatusStateV0(caCert string, idps map[string]interface{}) []interface{} {
+	return []interface{}{map[string]interface{}{
+		"apiserver": []interface{}{map[string]interface{}{
+			"endpoint": "https://kubernetes.example.com",
+			"ca_cert":  caCert,
+		}},
+		"service_account_issuer": "https://oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE",
+		"aws_iam_idps":           idps,
+	}}
+}
+
+func TestResourceRedfoxClusterStatusStateUpgradeV0(t *testing.T) {
+	runStateUpgradeTests(t, resourceRedfoxClusterStatusStateUpgradeV0, []stateUpgradeTestCase{
+		{
+			name:     "copies resource_version and generation from metadata",
+			rawState: map[string]interface{}{"metadata": testMetadataStateV0()},
+			expected: map[string]interface{}{"metadata": testMetadataStateV0(), "resource_version": "100", "generation": 1},
+		},
+		{
+			name:     "no metadata",
+			rawState: map[string]interface{}{"id": "redfox-metadata/test"},
+			expected: map[string]interface{}{"id": "redfox-metadata/test"},
+		},
+		{
+			name:     "nil state",
+			rawState: nil,
+			expected: map[string]interface{}{},
+		},
+	})
+}
+
+func TestResourceRedfoxClusterStatusStateUpgradeV1(t *testing.T) {
+	cert, _, caCert := testCaCert(t, "kubernetes")
+	arn := "arn:aws:iam::123456789012:oidc-provider/oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE"
+
+	expected := testClusterStatusStateV0(caCert, map[string]interface{}{"default": arn, "other": "not an ARN"})
+	status := expected[0].(map[string]interface{})
+	status["apiserver"].([]interface{})[0].(map[string]interface{})["ca_cert_info"] = flattenCaCertInfo([]*x509.Certificate{cert})
+	status["aws_iam_idp_entries"] = []any{map[string]any{
+		"key":           "default",
+		"partition":     "aws",
+		"account_id":    "123456789012",
+		"provider_host": "oidc.eks.ap-northeast-2.amazonaws.com/id/EXAMPLE",
+	}}
+
+	invalid := testClusterStatusStateV0("invalid", nil)
+	invalidStatus := invalid[0].(map[string]interface{})
+	invalidStatus["apiserver"].([]interface{})[0].(map[string]interface{})["ca_cert_info"] = []any{}
+	invalidStatus["aws_iam_idp_entries"] = []any{}
+
+	runStateUpgradeTests(t, resourceRedfoxClusterStatusStateUpgradeV1, []stateUpgradeTestCase{
+		{
+			name:     "parses ca_cert and aws_iam_idps",
+			rawState: map[string]interface{}{"status": testClusterStatusStateV0(caCert, map[string]interface{}{"default": arn, "other": "not an ARN"})},
+			expected: map[string]interface{}{"status": expected},
+		},
+		{
+			name:     "invalid ca_cert",
+			rawState: map[string]interface{}{"status": testClusterStatusStateV0("invalid", nil)},
+			expected: map[string]interface{}{"status": invalid},
+		},
+		{
+			name:     "no status",
+			rawState: map[string]interface{}{"id": "redfox-metadata/test"},
+			expected: map[string]interface{}{"id": "redfox-metadata/test"},
+		},
+	})
+}
+
+func TestResourceRedfoxClusterStatusStateUpgradeV2(t *testing.T) {
+	_, _, caCert := testCaCert(t, "kubernetes")
+	status := testClusterStatusStateV0(caCert, nil)
+	runStateUpgradeTests(t, resourceRedfoxClusterStatusStateUpgradeV2, []stateUpgradeTestCase{
+		{
+			name:     "copies status.apiserver",
+			rawState: map[string]interface{}{"status": status},
+			expected: map[string]interface{}{
+				"status": status,
+				"apiserver": []interface{}{map[string]interface{}{
+					"endpoint": "https://kubernetes.example.com",
+					"ca_cert":  caCert,
+				}},
+			},
+		},
+		{
+			name:     "no status",
+			rawState: map[string]interface{}{"id": "redfox-metadata/test"},
+			expected: map[string]interface{}{"id": "redfox-metadata/test"},
+		},
+	})
+}
+
+func TestResourceRedfoxClusterStatusStateUpgradeFromV0(t *testing.T) {
+	_, _, caCert := testCaCert(t, "kubernetes")
+	metadata := testMetadataStateV0()
+	delete(metadata[0].(map[string]interface{}), "generate_name")
+	actual := upgradeStateToCurrent(t, resourceRedfoxClusterStatus(), 0, map[string]interface{}{
+		"id":       "redfox-metadata/test",
+		"metadata": metadata,
+		"status":   testClusterStatusStateV0(caCert, map[string]interface{}{}),
+	})
+	if actual["resource_version"] != "100" {
+		t.Fatalf("expected resource_version from metadata, got %#v", actual["resource_version"])
+	}
+	apiserver := actual["apiserver"].([]interface{})[0].(map[string]interface{})
+	if apiserver["endpoint"] != "https://kubernetes.example.com" || apiserver["ca_cert"] != caCert {
+		t.Fatalf("expected apiserver from status, got %#v", apiserver)
+	}
+}
@@ -2,6 +2,7 @@
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
@@ -26,7 +27,8 @@ func expandClusterSpec(cluster []any) (*redfoxV1alpha1.ClusterSpec, error) {
 	obj.ServicePhase = in["service_phase"].(string)
 	obj.ServiceTag = in["service_tag"].(string)
 
-	rawRoles := in["roles"].([]any)
+	rawRoles := in["roles"].(*schema.Set).List()
+	sort.Slice(rawRoles, func(i, j int) bool { return rawRoles[i].(string) < rawRoles[j].(string) })
 	for _, role := range rawRoles {
 		obj.Roles = append(obj.Roles, redfoxV1alpha1.ClusterRole(role.(string)))
 	}
@@ -53,7 +55,9 @@ func flattenClusterSpec(in redfoxV1alpha1.ClusterSpec, d *schema.ResourceData, m
 	att["infra_vendor"] = in.InfraVendor
 	att["service_phase"] = in.ServicePhase
 	att["service_tag"] = in.ServiceTag
-	att["roles"] = in.Roles
+	att["roles"] = lo.Map[redfoxV1alpha1.ClusterRole, any](in.Roles, func(x redfoxV1alpha1.ClusterRole, _ int) any {
+		return string(x)
+	})
 	att["vpc_id"] = in.VpcId
 	if in.DatabaseSubnetIds != nil {
 		att["database_subnet_ids"] = in.DatabaseSubnetIds
@@ -75,12 +79,14 @@ func expandClusterStatus(cluster []any) (*redfoxV1alpha1.ClusterStatus, error) {
 
 	// apiserver is required field
 	rawApiserver := in["apiserver"].([]any)
-	if len(rawApiserver) == 0 || rawApiserver[0] == nil {
+	if len(rawApiserver) == 0 {
 		return nil, fmt.Errorf("`apiserver` is required block")
 	}
-	inApiserver := rawApiserver[0].(map[string]any)
-	obj.Apiserver.Endpoint = inApiserver["endpoint"].(string)
-	obj.Apiserver.CaCert = inApiserver["ca_cert"].(string)
+	// The block is empty when every attribute is read from `from_kubeconfig`
+	if inApiserver, ok := rawApiserver[0].(map[string]any); ok {
+		obj.Apiserver.Endpoint = inApiserver["endpoint"].(string)
+		obj.Apiserver.CaCert = inApiserver["ca_cert"].(string)
+	}
 
 	// aws_iam_external_idps is optional field
 	if rawAwsIdps, found := in["aws_iam_idps"]; found {
@@ -95,10 +101,15 @@ func expandClusterStatus(cluster []any) (*redfoxV1alpha1.ClusterStatus, error) {
 func flattenClusterStatus(in redfoxV1alpha1.ClusterStatus, d *schema.ResourceData, meta interface{}) ([]any, error) {
 	att := map[string]any{}
 	att["service_account_issuer"] = in.ServiceAccountIssuer
-	apiserver := map[string]string{}
+	apiserver := map[string]any{}
 	apiserver["endpoint"] = in.Apiserver.Endpoint
 	apiserver["ca_cert"] = in.Apiserver.CaCert
+	apiserver["ca_cert_info"] = []any{}
+	if certs, err := decodeCaCert(in.Apiserver.CaCert); err == nil {
+		apiserver["ca_cert_info"] = flattenCaCertInfo(certs)
+	}
 	att["apiserver"] = []any{apiserver}
 	att["aws_iam_idps"] = in.AwsIamIdps
+	att["aws_iam_idp_entries"] = flattenAwsIamIdpEntries(in.AwsIamIdps)
 	return []any{att}, nil
 }
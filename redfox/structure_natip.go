@@ -21,16 +21,22 @@ func expandNatIpSpec(natip []any) (*redfoxV1alpha1.NatIpSpec, error) {
 	}
 
 	// Required field
-	cidrs := lo.Map[any, string](in["cidrs"].([]any), func(x any, _ int) string {
+	cidrs := lo.Map[any, string](in["cidrs"].(*schema.Set).List(), func(x any, _ int) string {
 		return x.(string)
 	})
-	obj.Cidrs = cidrs
+	obj.Cidrs = canonicalCidrs(cidrs)
+
+	ipType, err := resolveNatIpType(obj.IpType, obj.Cidrs)
+	if err != nil {
+		return nil, err
+	}
+	obj.IpType = ipType
 	return obj, nil
 }
 
 func flattenNatIpSpec(in redfoxV1alpha1.NatIpSpec, d *schema.ResourceData, meta interface{}) ([]any, error) {
 	att := map[string]any{}
 	att["ip_type"] = string(in.IpType)
-	att["cidrs"] = in.Cidrs
+	att["cidrs"] = canonicalCidrs(in.Cidrs)
 	return []any{att}, nil
 }
@@ -0,0 +1,50 @@
+package redfox
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	uidChangeWarn     = "warn"
+	uidChangeRecreate = "recreate"
+)
+
+func onUidChangeSchema(objectName string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Description:  fmt.Sprintf("What to do when the %s was deleted and recreated outside of Terraform, detected by a changed `metadata.uid`. `warn` adopts the new object and emits a warning, `recreate` removes it from state so that Terraform plans to create it again.", objectName),
+		Optional:     true,
+		Default:      uidChangeWarn,
+		ValidateFunc: validation.StringInSlice([]string{uidChangeWarn, uidChangeRecreate}, false),
+	}
+}
+
+// checkUidChange compares the UID recorded in state with the UID of the object observed on the API server.
+// It reports whether the resource should be removed from state so that Terraform plans its recreation.
+// Data sources do not track UIDs and must not call it.
+func checkUidChange(d *schema.ResourceData, kind string, observed metav1.ObjectMeta) (bool, diag.Diagnostics) {
+	mode := d.Get("on_uid_change").(string)
+	recorded, _ := d.Get("metadata.0.uid").(string)
+	if recorded == "" || recorded == string(observed.UID) {
+		return false, nil
+	}
+
+	detail := fmt.Sprintf("%s %q was recreated outside of Terraform: the UID recorded in state is %q but the API server returned %q. Annotations, owner references and history attached to the previous object are lost.", kind, buildId(observed), recorded, observed.UID)
+	if mode == uidChangeRecreate {
+		return true, diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("%s was recreated outside of Terraform, planning to create it again", kind),
+			Detail:   detail,
+		}}
+	}
+	return false, diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("%s was recreated outside of Terraform", kind),
+		Detail:   detail + " Set `on_uid_change = \"recreate\"` to plan its recreation instead.",
+	}}
+}
@@ -3,9 +3,13 @@
 import (
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apiValidation "k8s.io/apimachinery/pkg/api/validation"
@@ -189,6 +193,19 @@ func validateTerminationGracePeriodSeconds(value interface{}, key string) (ws []
 	return
 }
 
+func validateDuration(value interface{}, key string) (ws []string, es []error) {
+	v := value.(string)
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		es = append(es, fmt.Errorf("%s (%q) must be a duration such as %q: %s", key, v, "720h", err))
+		return
+	}
+	if d < 0 {
+		es = append(es, fmt.Errorf("%s (%q) must not be negative", key, v))
+	}
+	return
+}
+
 func validateIntGreaterThan(minValue int) func(value interface{}, key string) (ws []string, es []error) {
 	return func(value interface{}, key string) (ws []string, es []error) {
 		v := value.(int)
@@ -290,3 +307,29 @@ func validateTypeStringNullableIntOrPercent(v interface{}, key string) (ws []str
 
 	return
 }
+
+// validateEachMapValue applies validate to every value of a map during plan, reporting problems at the
+// path of their key. The SDK does not run the ValidateFunc of the Elem of a TypeMap.
+func validateEachMapValue(validate schema.SchemaValidateFunc) schema.SchemaValidateDiagFunc {
+	return func(value interface{}, path cty.Path) diag.Diagnostics {
+		m, _ := value.(map[string]interface{})
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var diags diag.Diagnostics
+		for _, k := range keys {
+			keyPath := append(path.Copy(), cty.IndexStep{Key: cty.StringVal(k)})
+			ws, es := validate(m[k], k)
+			for _, w := range ws {
+				diags = append(diags, diag.Diagnostic{Severity: diag.Warning, Summary: w, AttributePath: keyPath})
+			}
+			for _, e := range es {
+				diags = append(diags, diag.Diagnostic{Severity: diag.Error, Summary: e.Error(), AttributePath: keyPath})
+			}
+		}
+		return diags
+	}
+}
@@ -0,0 +1,119 @@
+package redfox
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	infraVendorAws   = "aws"
+	infraVendorGcp   = "gcp"
+	infraVendorAzure = "azure"
+)
+
+const (
+	gcpProjectIdPattern  = `[a-z][a-z0-9-]{4,28}[a-z0-9]`
+	gcpResourceName      = `[a-z]([-a-z0-9]{0,61}[a-z0-9])?`
+	gcpSelfLinkPrefix    = `(https://www\.googleapis\.com/compute/v1/)?`
+	azureGuidPattern     = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+	azureVnetIdPattern   = `/subscriptions/` + azureGuidPattern + `/resource[gG]roups/[^/]+/providers/Microsoft\.Network/virtualNetworks/[^/]+`
+	azureSubnetIdPattern = azureVnetIdPattern + `/subnets/[^/]+`
+)
+
+// vendorRule requires every value of a Cluster spec attribute to match pattern.
+type vendorRule struct {
+	attribute   string
+	pattern     *regexp.Regexp
+	description string
+}
+
+// clusterVendorRules are the format rules of Cluster spec attributes, keyed by lower-cased `infra_vendor`.
+var clusterVendorRules = map[string][]vendorRule{
+	infraVendorAws: {
+		{"infra_account_id", regexp.MustCompile(`^\d{12}$`), "a 12-digit AWS account ID"},
+		{"vpc_id", regexp.MustCompile(`^vpc-([0-9a-f]{8}|[0-9a-f]{17})$`), "a VPC ID such as `vpc-0123456789abcdef0`"},
+		{"database_subnet_ids", regexp.MustCompile(`^subnet-([0-9a-f]{8}|[0-9a-f]{17})$`), "a subnet ID such as `subnet-0123456789abcdef0`"},
+	},
+	infraVendorGcp: {
+		{"infra_account_id", regexp.MustCompile(`^` + gcpProjectIdPattern + `$`), "a GCP project ID"},
+		{"vpc_id", regexp.MustCompile(`^` + gcpSelfLinkPrefix + `projects/` + gcpProjectIdPattern + `/global/networks/` + gcpResourceName + `$`), "a network self-link such as `projects/<project>/global/networks/<name>`"},
+		{"database_subnet_ids", regexp.MustCompile(`^` + gcpSelfLinkPrefix + `projects/` + gcpProjectIdPattern + `/regions/[a-z0-9-]+/subnetworks/` + gcpResourceName + `$`), "a subnetwork self-link such as `projects/<project>/regions/<region>/subnetworks/<name>`"},
+	},
+	infraVendorOnPrem: {},
+	infraVendorAzure: {
+		{"infra_account_id", regexp.MustCompile(`^` + azureGuidPattern + `$`), "an Azure subscription ID (GUID)"},
+		{"vpc_id", regexp.MustCompile(`^` + azureVnetIdPattern + `$`), "a virtual network resource ID such as `/subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Network/virtualNetworks/<name>`"},
+		{"database_subnet_ids", regexp.MustCompile(`^` + azureSubnetIdPattern + `$`), "a subnet resource ID such as `/subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Network/virtualNetworks/<name>/subnets/<name>`"},
+	},
+}
+
+func knownInfraVendors() []string {
+	vendors := make([]string, 0, len(clusterVendorRules))
+	for vendor := range clusterVendorRules {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors)
+	return vendors
+}
+
+// validateInfraVendor warns about vendors without format rules, whose attributes are not validated.
+func validateInfraVendor(value interface{}, key string) (ws []string, es []error) {
+	v := value.(string)
+	if _, ok := clusterVendorRules[strings.ToLower(v)]; !ok {
+		ws = append(ws, fmt.Sprintf("%s (%q) is not a known vendor, attributes of the cluster are not validated against it. Known vendors: %s", key, v, strings.Join(knownInfraVendors(), ", ")))
+	}
+	return
+}
+
+// checkClusterVendorRules returns a description of every value of spec breaking the rules of vendor.
+func checkClusterVendorRules(vendor string, spec map[string]interface{}) []string {
+	var violations []string
+	for _, rule := range clusterVendorRules[strings.ToLower(vendor)] {
+		var values []string
+		switch v := spec[rule.attribute].(type) {
+		case string:
+			values = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					values = append(values, s)
+				}
+			}
+		}
+		for _, value := range values {
+			if value != "" && !rule.pattern.MatchString(value) {
+				violations = append(violations, fmt.Sprintf("%s = %q is not %s", rule.attribute, value, rule.description))
+			}
+		}
+	}
+	return violations
+}
+
+// customizeDiffClusterVendorRules validates the spec of a Cluster against the rules of its `infra_vendor`.
+// Validation waits for the vendor and the checked attributes to be known.
+func customizeDiffClusterVendorRules(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("spec.0.infra_vendor") {
+		return nil
+	}
+	vendor := d.Get("spec.0.infra_vendor").(string)
+	rules := clusterVendorRules[strings.ToLower(vendor)]
+
+	spec := map[string]interface{}{}
+	for _, rule := range rules {
+		key := "spec.0." + rule.attribute
+		if d.NewValueKnown(key) {
+			spec[rule.attribute] = d.Get(key)
+		}
+	}
+
+	violations := checkClusterVendorRules(vendor, spec)
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("spec of the cluster does not match infra_vendor %q:\n  - %s", vendor, strings.Join(violations, "\n  - "))
+}
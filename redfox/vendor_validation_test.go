@@ -0,0 +1,239 @@
+package redfox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckClusterVendorRules(t *testing.T) {
+	azureVnet := "/subscriptions/0b1f6471-1bf0-4dda-aec3-cb9272f09590/resourceGroups/network/providers/Microsoft.Network/virtualNetworks/main"
+	cases := []struct {
+		name       string
+		vendor     string
+		spec       map[string]interface{}
+		violations []string
+	}{
+		{
+			name:   "aws valid",
+			vendor: infraVendorAws,
+			spec: map[string]interface{}{
+				"infra_account_id":    "123456789012",
+				"vpc_id":              "vpc-0123456789abcdef0",
+				"database_subnet_ids": []interface{}{"subnet-01234567", "subnet-0123456789abcdef0"},
+			},
+		},
+		{
+			name:   "aws short vpc_id",
+			vendor: infraVendorAws,
+			spec:   map[string]interface{}{"vpc_id": "vpc-01234567"},
+		},
+		{
+			name:       "aws infra_account_id",
+			vendor:     infraVendorAws,
+			spec:       map[string]interface{}{"infra_account_id": "12345678901"},
+			violations: []string{`infra_account_id = "12345678901" is not a 12-digit AWS account ID`},
+		},
+		{
+			name:       "aws vpc_id",
+			vendor:     infraVendorAws,
+			spec:       map[string]interface{}{"vpc_id": "vpc-0123456789ABCDEF0"},
+			violations: []string{`vpc_id = "vpc-0123456789ABCDEF0" is not a VPC ID`},
+		},
+		{
+			name:       "aws database_subnet_ids",
+			vendor:     infraVendorAws,
+			spec:       map[string]interface{}{"database_subnet_ids": []interface{}{"subnet-01234567", "sub-01234567"}},
+			violations: []string{`database_subnet_ids = "sub-01234567" is not a subnet ID`},
+		},
+		{
+			name:   "aws vendor is case insensitive",
+			vendor: "AWS",
+			spec:   map[string]interface{}{"infra_account_id": "my-project"},
+			violations: []string{
+				`infra_account_id = "my-project" is not a 12-digit AWS account ID`,
+			},
+		},
+		{
+			name:   "gcp valid",
+			vendor: infraVendorGcp,
+			spec: map[string]interface{}{
+				"infra_account_id": "my-project-123",
+				"vpc_id":           "projects/my-project-123/global/networks/main",
+				"database_subnet_ids": []interface{}{
+					"projects/my-project-123/regions/asia-northeast3/subnetworks/db",
+					"https://www.googleapis.com/compute/v1/projects/my-project-123/regions/asia-northeast3/subnetworks/db-2",
+				},
+			},
+		},
+		{
+			name:       "gcp infra_account_id",
+			vendor:     infraVendorGcp,
+			spec:       map[string]interface{}{"infra_account_id": "123456789012"},
+			violations: []string{`infra_account_id = "123456789012" is not a GCP project ID`},
+		},
+		{
+			name:       "gcp vpc_id",
+			vendor:     infraVendorGcp,
+			spec:       map[string]interface{}{"vpc_id": "vpc-0123456789abcdef0"},
+			violations: []string{`vpc_id = "vpc-0123456789abcdef0" is not a network self-link`},
+		},
+		{
+			name:       "gcp database_subnet_ids",
+			vendor:     infraVendorGcp,
+			spec:       map[string]interface{}{"database_subnet_ids": []interface{}{"projects/my-project-123/global/networks/main"}},
+			violations: []string{`database_subnet_ids = "projects/my-project-123/global/networks/main" is not a subnetwork self-link`},
+		},
+		{
+			name:   "azure valid",
+			vendor: infraVendorAzure,
+			spec: map[string]interface{}{
+				"infra_account_id":    "0b1f6471-1bf0-4dda-aec3-cb9272f09590",
+				"vpc_id":              azureVnet,
+				"database_subnet_ids": []interface{}{azureVnet + "/subnets/db"},
+			},
+		},
+		{
+			name:       "azure infra_account_id",
+			vendor:     infraVendorAzure,
+			spec:       map[string]interface{}{"infra_account_id": "0b1f6471"},
+			violations: []string{`infra_account_id = "0b1f6471" is not an Azure subscription ID`},
+		},
+		{
+			name:       "azure vpc_id",
+			vendor:     infraVendorAzure,
+			spec:       map[string]interface{}{"vpc_id": azureVnet + "/subnets/db"},
+			violations: []string{`vpc_id = "` + azureVnet + `/subnets/db" is not a virtual network resource ID`},
+		},
+		{
+			name:       "azure database_subnet_ids",
+			vendor:     infraVendorAzure,
+			spec:       map[string]interface{}{"database_subnet_ids": []interface{}{azureVnet}},
+			violations: []string{`database_subnet_ids = "` + azureVnet + `" is not a subnet resource ID`},
+		},
+		{
+			name:   "on-prem has no rules",
+			vendor: infraVendorOnPrem,
+			spec: map[string]interface{}{
+				"infra_account_id":    "datacenter-1",
+				"vpc_id":              "vlan-100",
+				"database_subnet_ids": []interface{}{"10.0.0.0/24"},
+			},
+		},
+		{
+			name:   "unknown vendor has no rules",
+			vendor: "openstack",
+			spec:   map[string]interface{}{"infra_account_id": "tenant"},
+		},
+		{
+			name:   "empty values are left to the schema",
+			vendor: infraVendorAws,
+			spec:   map[string]interface{}{"infra_account_id": "", "database_subnet_ids": []interface{}{""}},
+		},
+		{
+			name:   "every violation is reported",
+			vendor: infraVendorAws,
+			spec: map[string]interface{}{
+				"infra_account_id":    "1",
+				"vpc_id":              "2",
+				"database_subnet_ids": []interface{}{"3", "4"},
+			},
+			violations: []string{"infra_account_id", "vpc_id", `database_subnet_ids = "3"`, `database_subnet_ids = "4"`},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := checkClusterVendorRules(tc.vendor, tc.spec)
+			if len(violations) != len(tc.violations) {
+				t.Fatalf("expected %d violations, got %q", len(tc.violations), violations)
+			}
+			for i, expected := range tc.violations {
+				if !strings.HasPrefix(violations[i], expected) {
+					t.Errorf("expected violation %d to start with %q, got %q", i, expected, violations[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateInfraVendor(t *testing.T) {
+	for _, vendor := range []string{infraVendorAws, infraVendorGcp, infraVendorAzure, infraVendorOnPrem, "AWS"} {
+		if ws, es := validateInfraVendor(vendor, "spec.0.infra_vendor"); len(ws) > 0 || len(es) > 0 {
+			t.Errorf("%s: expected no warning, got %q %q", vendor, ws, es)
+		}
+	}
+
+	ws, es := validateInfraVendor("openstack", "spec.0.infra_vendor")
+	if len(es) > 0 {
+		t.Fatalf("expected only a warning, got %q", es)
+	}
+	if len(ws) != 1 || !strings.Contains(ws[0], `spec.0.infra_vendor ("openstack") is not a known vendor`) || !strings.Contains(ws[0], "aws, azure, gcp, on-prem") {
+		t.Fatalf("expected a warning listing the known vendors, got %q", ws)
+	}
+}
+
+func testClusterConfig(spec map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{
+		"cluster_name":     "test",
+		"cluster_region":   "ap-northeast-2",
+		"cluster_group":    "test",
+		"service_phase":    "dev",
+		"service_tag":      "test",
+		"cluster_engine":   "eks",
+		"infra_vendor":     infraVendorAws,
+		"infra_account_id": "123456789012",
+		"roles":            []interface{}{"ingame"},
+		"vpc_id":           "vpc-0123456789abcdef0",
+	}
+	for k, v := range spec {
+		merged[k] = v
+	}
+	return map[string]interface{}{
+		"metadata": []interface{}{map[string]interface{}{"name": "test"}},
+		"spec":     []interface{}{merged},
+	}
+}
+
+func TestCustomizeDiffClusterVendorRules(t *testing.T) {
+	cases := []struct {
+		name  string
+		spec  map[string]interface{}
+		error string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:  "invalid",
+			spec:  map[string]interface{}{"vpc_id": "vpc-1", "database_subnet_ids": []interface{}{"subnet-1"}},
+			error: "spec of the cluster does not match infra_vendor \"aws\":\n  - vpc_id = \"vpc-1\" is not a VPC ID such as `vpc-0123456789abcdef0`\n  - database_subnet_ids = \"subnet-1\"",
+		},
+		{
+			name:  "invalid for another vendor",
+			spec:  map[string]interface{}{"infra_vendor": infraVendorGcp},
+			error: `infra_account_id = "123456789012" is not a GCP project ID`,
+		},
+		{
+			name: "unknown vendor",
+			spec: map[string]interface{}{"infra_vendor": testUnknown, "vpc_id": "vpc-1"},
+		},
+		{
+			name: "unknown attribute",
+			spec: map[string]interface{}{"vpc_id": testUnknown},
+		},
+		{
+			name: "vendor without rules",
+			spec: map[string]interface{}{"infra_vendor": "openstack", "vpc_id": "network-1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := testResourceDiff(t, resourceRedfoxCluster(), customizeDiffClusterVendorRules, nil, testClusterConfig(tc.spec), nil)
+			switch {
+			case tc.error == "" && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case tc.error != "" && (err == nil || !strings.Contains(err.Error(), tc.error)):
+				t.Fatalf("expected an error containing %q, got %v", tc.error, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package redfox
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+func waitForSchema(objectName string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: fmt.Sprintf("Block until fields of the %s satisfy the given conditions, or the create (or update) timeout expires.", objectName),
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"fields": {
+					Type:        schema.TypeMap,
+					Description: "Map of JSONPath-style field paths, e.g. `status.apiserver.endpoint` or `status.service_account_issuer`, to regular expressions their value must match. An empty expression only requires the field to be set.",
+					Required:    true,
+					Elem: &schema.Schema{
+						Type: schema.TypeString,
+					},
+					ValidateDiagFunc: validateWaitForFields,
+				},
+			},
+		},
+	}
+}
+
+func expandWaitForFields(in []interface{}) map[string]string {
+	if len(in) == 0 || in[0] == nil {
+		return nil
+	}
+	m := in[0].(map[string]interface{})
+	return expandStringMap(m["fields"].(map[string]interface{}))
+}
+
+var simpleFieldPathRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\[\]]+$`)
+
+// validateWaitForFields checks every field path and expression of `wait_for.fields` during plan. The
+// SDK does not run the validation of the Elem of a map, and a condition failing during apply would
+// taint the already patched object.
+func validateWaitForFields(value interface{}, path cty.Path) diag.Diagnostics {
+	fields, _ := value.(map[string]interface{})
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var diags diag.Diagnostics
+	for _, field := range keys {
+		fieldPath := append(path.Copy(), cty.IndexStep{Key: cty.StringVal(field)})
+		if _, err := parseWaitForField(field); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid field path",
+				Detail:        err.Error(),
+				AttributePath: fieldPath,
+			})
+		}
+		expression, _ := fields[field].(string)
+		if _, err := regexp.Compile(expression); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid regular expression",
+				Detail:        fmt.Sprintf("expression %q for field %q: %s", expression, field, err),
+				AttributePath: fieldPath,
+			})
+		}
+	}
+	return diags
+}
+
+// parseWaitForField parses the field path of a `wait_for` condition.
+func parseWaitForField(field string) (*jsonpath.JSONPath, error) {
+	path := strings.Trim(strings.TrimPrefix(strings.TrimSuffix(field, "}"), "{"), ".")
+	// Terraform attribute names are accepted for plain paths, the API uses camelCase
+	if simpleFieldPathRegexp.MatchString(path) {
+		path = snakeToCamel(path)
+	}
+
+	j := jsonpath.New(field)
+	j.AllowMissingKeys(true)
+	if err := j.Parse("{." + path + "}"); err != nil {
+		return nil, fmt.Errorf("invalid field path %q: %s", field, err)
+	}
+	return j, nil
+}
+
+// unmetWaitConditions evaluates fields against obj and returns a description of every condition which
+// is not satisfied yet, sorted by field path.
+func unmetWaitConditions(obj interface{}, fields map[string]string) ([]string, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var unmet []string
+	for field, expression := range fields {
+		j, err := parseWaitForField(field)
+		if err != nil {
+			return nil, err
+		}
+		results, err := j.FindResults(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate field path %q: %s", field, err)
+		}
+
+		value := ""
+		if len(results) > 0 && len(results[0]) > 0 && results[0][0].IsValid() {
+			value = fmt.Sprintf("%v", results[0][0].Interface())
+		}
+
+		if expression == "" {
+			if value == "" {
+				unmet = append(unmet, fmt.Sprintf("%s is not set", field))
+			}
+			continue
+		}
+		matched, err := regexp.MatchString(expression, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q for field %q: %s", expression, field, err)
+		}
+		if !matched {
+			unmet = append(unmet, fmt.Sprintf("%s = %q does not match %q", field, value, expression))
+		}
+	}
+	sort.Strings(unmet)
+	return unmet, nil
+}
+
+// snakeToCamel converts Terraform attribute names such as `service_account_issuer` to `serviceAccountIssuer`.
+func snakeToCamel(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
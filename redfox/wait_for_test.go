@@ -0,0 +1,156 @@
+package redfox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	redfoxV1alpha1 "github.com/krafton-hq/redfox/pkg/apis/redfox/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testConditionsObject is an object reporting its readiness with conditions, as most Kubernetes objects do.
+type testConditionsObject struct {
+	Status struct {
+		Conditions []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+func TestUnmetWaitConditions(t *testing.T) {
+	cluster := &redfoxV1alpha1.Cluster{
+		Status: redfoxV1alpha1.ClusterStatus{
+			Apiserver:            redfoxV1alpha1.ApiserverInfo{Endpoint: "https://dev.example.com"},
+			ServiceAccountIssuer: "https://oidc.example.com/id/EXAMPLE",
+			AwsIamIdps:           map[string]string{"dev": "arn:aws:iam::123456789012:oidc-provider/oidc.example.com/id/EXAMPLE"},
+		},
+	}
+	ready := &testConditionsObject{}
+	ready.Status.Conditions = []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue}}
+	notReady := &testConditionsObject{}
+	notReady.Status.Conditions = []metav1.Condition{{Type: "Ready", Status: metav1.ConditionFalse}}
+
+	const readyField = `{.status.conditions[?(@.type=="Ready")].status}`
+	cases := []struct {
+		name   string
+		obj    interface{}
+		fields map[string]string
+		unmet  []string
+	}{
+		{
+			name:   "matching field",
+			obj:    cluster,
+			fields: map[string]string{"status.apiserver.endpoint": "^https://"},
+		},
+		{
+			name:   "Terraform attribute names",
+			obj:    cluster,
+			fields: map[string]string{"status.service_account_issuer": "oidc", "status.aws_iam_idps.dev": ":123456789012:"},
+		},
+		{
+			name:   "field set",
+			obj:    cluster,
+			fields: map[string]string{"status.service_account_issuer": ""},
+		},
+		{
+			name:   "mismatching field",
+			obj:    cluster,
+			fields: map[string]string{"status.apiserver.endpoint": "^http://", "status.service_account_issuer": "^https://"},
+			unmet:  []string{`status.apiserver.endpoint = "https://dev.example.com" does not match "^http://"`},
+		},
+		{
+			name:   "missing field",
+			obj:    &redfoxV1alpha1.Cluster{},
+			fields: map[string]string{"status.service_account_issuer": "", "status.aws_iam_idps.dev": "arn"},
+			unmet:  []string{`status.aws_iam_idps.dev = "" does not match "arn"`, "status.service_account_issuer is not set"},
+		},
+		{
+			name:   "ready condition",
+			obj:    ready,
+			fields: map[string]string{readyField: "^True$"},
+		},
+		{
+			name:   "not ready condition",
+			obj:    notReady,
+			fields: map[string]string{readyField: "^True$"},
+			unmet:  []string{readyField + ` = "False" does not match "^True$"`},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			unmet, err := unmetWaitConditions(tc.obj, tc.fields)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(unmet, tc.unmet) {
+				t.Errorf("unmetWaitConditions() = %q, want %q", unmet, tc.unmet)
+			}
+		})
+	}
+}
+
+func TestValidateWaitForFields(t *testing.T) {
+	path := cty.GetAttrPath("wait_for").IndexInt(0).GetAttr("fields")
+	cases := []struct {
+		name   string
+		fields map[string]interface{}
+		errors map[string]string
+	}{
+		{
+			name:   "valid",
+			fields: map[string]interface{}{"status.apiserver.endpoint": "^https://", `{.status.conditions[?(@.type=="Ready")].status}`: "True", "status.service_account_issuer": ""},
+		},
+		{
+			name:   "invalid expression",
+			fields: map[string]interface{}{"status.apiserver.endpoint": "(https", "status.service_account_issuer": ".+"},
+			errors: map[string]string{"status.apiserver.endpoint": "Invalid regular expression"},
+		},
+		{
+			name:   "invalid field path",
+			fields: map[string]interface{}{"status.conditions[": "True"},
+			errors: map[string]string{"status.conditions[": "Invalid field path"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := validateWaitForFields(tc.fields, path)
+			if len(diags) != len(tc.errors) {
+				t.Fatalf("expected %d errors, got %v", len(tc.errors), diags)
+			}
+			for _, d := range diags {
+				key := d.AttributePath[len(d.AttributePath)-1].(cty.IndexStep).Key.AsString()
+				if !d.AttributePath[:len(path)].Equals(path) || !strings.Contains(d.Summary, tc.errors[key]) {
+					t.Errorf("unexpected error %q at %#v", d.Summary, d.AttributePath)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForFieldsValidatedDuringPlan(t *testing.T) {
+	r := resourceRedfoxCluster()
+	config := testClusterConfig(nil)
+	config["wait_for"] = []interface{}{map[string]interface{}{"fields": map[string]interface{}{"status.apiserver.endpoint": "(https"}}}
+	raw := testResourceConfig(t, r, config)
+	if diags := r.Validate(raw); !diags.HasError() {
+		t.Fatal("expected the invalid expression to fail the validation")
+	}
+}
+
+func TestSnakeToCamel(t *testing.T) {
+	cases := map[string]string{
+		"status":                          "status",
+		"service_account_issuer":          "serviceAccountIssuer",
+		"status.apiserver.ca_cert":        "status.apiserver.caCert",
+		"status.aws_iam_idps":             "status.awsIamIdps",
+		"spec.database_subnet_ids[0]":     "spec.databaseSubnetIds[0]",
+		"serviceAccountIssuer":            "serviceAccountIssuer",
+		"status.service_account_issuer_":  "status.serviceAccountIssuer",
+		"status.aws_iam_idps.dev_account": "status.awsIamIdps.devAccount",
+	}
+	for in, want := range cases {
+		if got := snakeToCamel(in); got != want {
+			t.Errorf("snakeToCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}